@@ -0,0 +1,49 @@
+package jobhandler
+
+import(
+    "context"
+    "sync"
+)
+
+// TryDo races the blocking acquisition acquire against the jobhandler being stopped.
+// acquire is called with a context that is cancelled when ctx is done
+// or the jobhandler is stopped, e.g.
+//
+//     jh.TryDo(ctx, func (ctx context.Context) error {
+//         return sem.Acquire(ctx, 1)
+//     })
+//
+// Returns true if acquire returned nil and false if it returned an error
+// or the jobhandler was already stopped.
+func (jh *JobHandler) TryDo(ctx context.Context, acquire func(context.Context) error) bool {
+    if jh.Stopped() {
+        return false
+    }
+    ctx, cancel := jh.withStop(ctx)
+    defer cancel()
+    return acquire(ctx) == nil
+}
+
+// TryLock locks l unless ctx is done or the jobhandler is stopped first.
+// Returns true if l is locked and false if not.
+// If TryLock gives up while l.Lock is blocked,
+// l is unlocked again as soon as it is acquired.
+func (jh *JobHandler) TryLock(ctx context.Context, l sync.Locker) bool {
+    return jh.TryDo(ctx, func (ctx context.Context) error {
+        locked := make(chan struct{})
+        go func() {
+            l.Lock()
+            close(locked)
+        }()
+        select {
+        case <-locked:
+            return nil
+        case <-ctx.Done():
+            go func() {
+                <-locked
+                l.Unlock()
+            }()
+            return ctx.Err()
+        }
+    })
+}
@@ -0,0 +1,60 @@
+package jobhandler
+import(
+    "context"
+    "sync"
+    "testing"
+)
+
+func TestTryDo(t *testing.T) {
+    t.Run("acquired", func (t *testing.T) {
+        jh := New(context.Background())
+        if !jh.TryDo(context.Background(), func (ctx context.Context) error { return nil }) {
+            t.Fatal("unable to acquire")
+        }
+        jh.Stop()
+        jh.WaitAll()
+    })
+    t.Run("stop while blocked", func (t *testing.T) {
+        jh := New(context.Background())
+        go jh.Stop()
+        if jh.TryDo(context.Background(), func (ctx context.Context) error {
+            <-ctx.Done()
+            return ctx.Err()
+        }) {
+            t.Fatal("should not acquire")
+        }
+        jh.WaitAll()
+    })
+    t.Run("closed jobhandler", func (t *testing.T) {
+        jh := New(context.Background())
+        jh.Stop()
+        didRunFn := false
+        if jh.TryDo(context.Background(), func (ctx context.Context) error {
+            didRunFn = true
+            return nil
+        }) {
+            t.Fatal("should not acquire")
+        }
+        if didRunFn {
+            t.Fatal("function did run")
+        }
+    })
+}
+
+func TestTryLock(t *testing.T) {
+    var mu sync.Mutex
+    jh := New(context.Background())
+    if !jh.TryLock(context.Background(), &mu) {
+        t.Fatal("unable to lock")
+    }
+    go jh.Stop()
+    if jh.TryLock(context.Background(), &mu) {
+        t.Fatal("should not lock")
+    }
+    mu.Unlock()
+    if !mu.TryLock() {
+        mu.Lock()
+    }
+    mu.Unlock()
+    jh.WaitAll()
+}
@@ -0,0 +1,233 @@
+package admin
+
+import(
+    "encoding/json"
+    "github.com/cblach/jobhandler"
+    "net/http"
+    "slices"
+    "strconv"
+    "sync"
+    "time"
+)
+
+// A Server is an http.Handler that controls a registry of jobhandlers:
+//
+//     GET  /handlers                   snapshots of all jobhandlers
+//     GET  /handlers/{name}            snapshot of a jobhandler
+//     POST /handlers/{name}/stop       Stop
+//     POST /handlers/{name}/drain      Drain, with the lame duck period
+//                                      in the query parameter lameduck, e.g. 30s
+//     POST /handlers/{name}/forcestop  ForceStop
+//     POST /handlers/{name}/limit      SetMaxConcurrent, with the bound
+//                                      in the query parameter n
+//     POST /handlers/{name}/wake       Wake, with the sleep name
+//                                      in the query parameter sleep
+//
+// Stop and Drain respond 202 Accepted without waiting for the jobhandler
+// to stop, and 409 Conflict if it is already stopped.
+// Wake responds with the number of woken jobs as JSON, e.g. {"woken":1}.
+// The other endpoints respond with the snapshot of the jobhandler as JSON.
+type Server struct {
+    mu       sync.Mutex
+    handlers map[string]*jobhandler.JobHandler
+    auth     func(*http.Request) error
+    mux      *http.ServeMux
+}
+
+// An Option configures a Server created by NewServer.
+type Option func(*Server)
+
+// WithAuth makes the server call auth for each request, and reject
+// the request with 403 Forbidden if auth returns an error.
+// By default all requests are served.
+func WithAuth(auth func(r *http.Request) error) Option {
+    return func(s *Server) {
+        s.auth = auth
+    }
+}
+
+// A Snapshot describes the state of a jobhandler.
+type Snapshot struct {
+    Name          string                  `json:"name"`
+    State         string                  `json:"state"`
+    Ready         bool                    `json:"ready"`
+    StopCause     string                  `json:"stop_cause,omitempty"`
+    Running       int64                   `json:"running"`
+    Queued        int                     `json:"queued"`
+    MaxConcurrent int64                   `json:"max_concurrent"`
+    Accepted      uint64                  `json:"accepted"`
+    Rejected      uint64                  `json:"rejected"`
+    Stats         jobhandler.Stats        `json:"stats"`
+    Jobs          []jobhandler.JobInfo    `json:"jobs"`
+    Waiters       []jobhandler.WaiterInfo `json:"waiters"`
+}
+
+// NewServer creates a server with an empty registry.
+func NewServer(opts ...Option) *Server {
+    s := &Server{handlers: make(map[string]*jobhandler.JobHandler)}
+    for _, opt := range opts {
+        opt(s)
+    }
+    s.mux = http.NewServeMux()
+    s.mux.HandleFunc("GET /handlers", s.list)
+    s.mux.HandleFunc("GET /handlers/{name}", s.handle(s.snapshot))
+    s.mux.HandleFunc("POST /handlers/{name}/stop", s.handle(s.stop))
+    s.mux.HandleFunc("POST /handlers/{name}/drain", s.handle(s.drain))
+    s.mux.HandleFunc("POST /handlers/{name}/forcestop", s.handle(s.forceStop))
+    s.mux.HandleFunc("POST /handlers/{name}/limit", s.handle(s.limit))
+    s.mux.HandleFunc("POST /handlers/{name}/wake", s.handle(s.wake))
+    return s
+}
+
+// Register adds jh to the registry under name, replacing any jobhandler
+// registered under the same name.
+func (s *Server) Register(name string, jh *jobhandler.JobHandler) {
+    s.mu.Lock()
+    s.handlers[name] = jh
+    s.mu.Unlock()
+}
+
+// Unregister removes the jobhandler registered under name, if any.
+func (s *Server) Unregister(name string) {
+    s.mu.Lock()
+    delete(s.handlers, name)
+    s.mu.Unlock()
+}
+
+// ServeHTTP serves the admin interface, see Server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if s.auth != nil {
+        if err := s.auth(r); err != nil {
+            http.Error(w, err.Error(), http.StatusForbidden)
+            return
+        }
+    }
+    s.mux.ServeHTTP(w, r)
+}
+
+// handle adapts fn to serve the requests of the jobhandler
+// named by the path, responding 404 Not Found if there is none.
+func (s *Server) handle(fn func(http.ResponseWriter, *http.Request, *jobhandler.JobHandler)) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        s.mu.Lock()
+        jh := s.handlers[r.PathValue("name")]
+        s.mu.Unlock()
+        if jh == nil {
+            http.NotFound(w, r)
+            return
+        }
+        fn(w, r, jh)
+    }
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    names := make([]string, 0, len(s.handlers))
+    for name := range s.handlers {
+        names = append(names, name)
+    }
+    handlers := make([]*jobhandler.JobHandler, len(names))
+    slices.Sort(names)
+    for i, name := range names {
+        handlers[i] = s.handlers[name]
+    }
+    s.mu.Unlock()
+    snaps := make([]Snapshot, len(handlers))
+    for i, jh := range handlers {
+        snaps[i] = Snap(jh)
+        snaps[i].Name = names[i]
+    }
+    writeJSON(w, http.StatusOK, snaps)
+}
+
+func (s *Server) snapshot(w http.ResponseWriter, r *http.Request, jh *jobhandler.JobHandler) {
+    s.writeSnapshot(w, r, jh, http.StatusOK)
+}
+
+func (s *Server) stop(w http.ResponseWriter, r *http.Request, jh *jobhandler.JobHandler) {
+    if jh.Stopped() {
+        http.Error(w, "jobhandler already stopped", http.StatusConflict)
+        return
+    }
+    // Stop blocks during the pre-stop delay, see WithPreStopDelay
+    go jh.Stop()
+    s.writeSnapshot(w, r, jh, http.StatusAccepted)
+}
+
+func (s *Server) drain(w http.ResponseWriter, r *http.Request, jh *jobhandler.JobHandler) {
+    var lameDuck time.Duration
+    if q := r.URL.Query().Get("lameduck"); q != "" {
+        d, err := time.ParseDuration(q)
+        if err != nil || d < 0 {
+            http.Error(w, "invalid lameduck duration: " + q, http.StatusBadRequest)
+            return
+        }
+        lameDuck = d
+    }
+    if jh.Stopped() {
+        http.Error(w, "jobhandler already stopped", http.StatusConflict)
+        return
+    }
+    go jh.Drain(lameDuck)
+    s.writeSnapshot(w, r, jh, http.StatusAccepted)
+}
+
+func (s *Server) forceStop(w http.ResponseWriter, r *http.Request, jh *jobhandler.JobHandler) {
+    jh.ForceStop()
+    s.writeSnapshot(w, r, jh, http.StatusOK)
+}
+
+func (s *Server) limit(w http.ResponseWriter, r *http.Request, jh *jobhandler.JobHandler) {
+    q := r.URL.Query().Get("n")
+    n, err := strconv.ParseInt(q, 10, 64)
+    if err != nil {
+        http.Error(w, "invalid limit: " + q, http.StatusBadRequest)
+        return
+    }
+    jh.SetMaxConcurrent(n)
+    s.writeSnapshot(w, r, jh, http.StatusOK)
+}
+
+func (s *Server) wake(w http.ResponseWriter, r *http.Request, jh *jobhandler.JobHandler) {
+    sleep := r.URL.Query().Get("sleep")
+    if sleep == "" {
+        http.Error(w, "missing sleep name", http.StatusBadRequest)
+        return
+    }
+    writeJSON(w, http.StatusOK, struct {
+        Woken int `json:"woken"`
+    }{jh.Wake(sleep)})
+}
+
+func (s *Server) writeSnapshot(w http.ResponseWriter, r *http.Request, jh *jobhandler.JobHandler, status int) {
+    snap := Snap(jh)
+    snap.Name = r.PathValue("name")
+    writeJSON(w, status, snap)
+}
+
+// Snap takes a snapshot of jh, named by the name of jh.
+func Snap(jh *jobhandler.JobHandler) Snapshot {
+    snap := Snapshot{
+        Name:          jh.Name(),
+        State:         jh.State().String(),
+        Ready:         jh.Ready(),
+        Running:       jh.Running(),
+        Queued:        jh.Queued(),
+        MaxConcurrent: jh.MaxConcurrent(),
+        Accepted:      jh.Accepted(),
+        Rejected:      jh.Rejected(),
+        Stats:         jh.Stats(),
+        Jobs:          jh.Jobs(),
+        Waiters:       jh.Waiters(),
+    }
+    if err := jh.StopCause(); err != nil {
+        snap.StopCause = err.Error()
+    }
+    return snap
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
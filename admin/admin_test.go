@@ -0,0 +1,88 @@
+package admin
+import(
+    "context"
+    "encoding/json"
+    "errors"
+    "github.com/cblach/jobhandler"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestServer(t *testing.T) {
+    jh := jobhandler.New(context.Background(), jobhandler.WithName("worker"))
+    s := NewServer(WithAuth(func(r *http.Request) error {
+        if r.Header.Get("Authorization") != "Bearer secret" {
+            return errors.New("unauthorized")
+        }
+        return nil
+    }))
+    s.Register("worker", jh)
+    do := func(method, path string) (*httptest.ResponseRecorder, Snapshot) {
+        req := httptest.NewRequest(method, path, nil)
+        req.Header.Set("Authorization", "Bearer secret")
+        rec := httptest.NewRecorder()
+        s.ServeHTTP(rec, req)
+        var snap Snapshot
+        json.Unmarshal(rec.Body.Bytes(), &snap)
+        return rec, snap
+    }
+    rec := httptest.NewRecorder()
+    s.ServeHTTP(rec, httptest.NewRequest("GET", "/handlers/worker", nil))
+    if rec.Code != http.StatusForbidden {
+        t.Fatal("expected unauthorized request to be forbidden, got", rec.Code)
+    }
+    if rec, _ := do("GET", "/handlers/nobody"); rec.Code != http.StatusNotFound {
+        t.Fatal("expected unknown jobhandler to be not found, got", rec.Code)
+    }
+    rec, snap := do("GET", "/handlers/worker")
+    if rec.Code != http.StatusOK || snap.Name != "worker" || snap.State != "running" || !snap.Ready {
+        t.Fatal("unexpected snapshot", rec.Code, snap)
+    }
+    rec, snap = do("POST", "/handlers/worker/limit?n=3")
+    if rec.Code != http.StatusOK || snap.MaxConcurrent != 3 || jh.MaxConcurrent() != 3 {
+        t.Fatal("limit not set", rec.Code, snap)
+    }
+    if rec, _ := do("POST", "/handlers/worker/limit?n=x"); rec.Code != http.StatusBadRequest {
+        t.Fatal("expected invalid limit to be a bad request, got", rec.Code)
+    }
+    slept := make(chan bool)
+    go func() {
+        slept <- jh.TrySleepNamed("poll", time.Hour)
+    }()
+    for {
+        rec, _ := do("POST", "/handlers/worker/wake?sleep=poll")
+        if rec.Code != http.StatusOK {
+            t.Fatal("unexpected wake status", rec.Code)
+        }
+        var woken struct{ Woken int }
+        json.Unmarshal(rec.Body.Bytes(), &woken)
+        if woken.Woken == 1 {
+            break
+        }
+        time.Sleep(time.Millisecond)
+    }
+    if !<-slept {
+        t.Fatal("woken sleep reported stop")
+    }
+    if rec, _ := do("POST", "/handlers/worker/wake"); rec.Code != http.StatusBadRequest {
+        t.Fatal("expected wake without sleep name to be a bad request, got", rec.Code)
+    }
+    if rec, _ := do("POST", "/handlers/worker/drain?lameduck=0s"); rec.Code != http.StatusAccepted {
+        t.Fatal("expected drain to be accepted, got", rec.Code)
+    }
+    jh.WaitAll()
+    if rec, _ := do("POST", "/handlers/worker/stop"); rec.Code != http.StatusConflict {
+        t.Fatal("expected stop of stopped jobhandler to conflict, got", rec.Code)
+    }
+    var snaps []Snapshot
+    rec = httptest.NewRecorder()
+    req := httptest.NewRequest("GET", "/handlers", nil)
+    req.Header.Set("Authorization", "Bearer secret")
+    s.ServeHTTP(rec, req)
+    json.Unmarshal(rec.Body.Bytes(), &snaps)
+    if len(snaps) != 1 || snaps[0].State != "stopped" {
+        t.Fatal("unexpected snapshots", snaps)
+    }
+}
@@ -0,0 +1,6 @@
+// Package admin serves an HTTP admin interface to drain jobhandlers remotely.
+//
+// It is meant for orchestration tooling that drains a specific worker,
+// instead of sending SIGTERM to the process and hoping. Mount a Server
+// on an internal listener and guard it with WithAuth.
+package admin
@@ -0,0 +1,203 @@
+package jobhandler
+
+import(
+    "errors"
+)
+
+// An Admission describes jobs to be taken on, see AdmissionStage.
+type Admission struct {
+    // Delta is the number of jobs.
+    Delta int
+    // Class is the job class, the group of TryFuncGroup or the name
+    // of TryNamed, or empty for other jobs.
+    Class string
+    // Forced is set for jobs that are taken regardless of the stages:
+    // critical jobs once the jobhandler is stopped, see TryCritical,
+    // and queued jobs flushed on stop, see QueueFlush. The error of Admit
+    // is ignored for them, but they are released when done like others.
+    Forced bool
+}
+
+// An AdmissionStage is a stage of the admission of jobs, e.g. a feature
+// flag kill switch or a quota per job class, see WithAdmission.
+// The bounds of WithRateLimit, WithMaxTotalJobs and WithBudget are
+// stages too, see WithAdmissionPipeline.
+type AdmissionStage interface {
+    // Admit returns nil to admit the jobs a describes, and otherwise
+    // the error whose text is recorded as rejection reason.
+    Admit(a Admission) error
+    // Undo undoes Admit of a when a later stage rejects the jobs.
+    Undo(a Admission)
+    // Release is called when jobs admitted by Admit are done. Jobs may be
+    // released in parts, e.g. jobs taken with TryN are released one call
+    // of Done at a time, and jobs done with the Done method of the
+    // jobhandler are released with an empty class. Jobs abandoned by
+    // ForceStop are released at once with an empty class.
+    Release(a Admission)
+}
+
+// AdmissionFunc adapts a function to an AdmissionStage
+// that keeps no state to undo or release.
+type AdmissionFunc func(a Admission) error
+
+// Admit calls f(a).
+func (f AdmissionFunc) Admit(a Admission) error {
+    return f(a)
+}
+
+// Undo does nothing.
+func (f AdmissionFunc) Undo(Admission) {}
+
+// Release does nothing.
+func (f AdmissionFunc) Release(Admission) {}
+
+// WithAdmission adds stages to the admission of the jobhandler.
+// Jobs are admitted in stages: the jobhandler must be running and not
+// paused by a failing probe, see WithProbe, the gates of the job class
+// must be open, see Gate, the stages must admit the jobs in order,
+// and finally the jobs must fit the bound of WithMaxConcurrent,
+// which is checked as they are counted and is not a stage.
+// The stages added by WithAdmission are followed by the built-in stages
+// of WithRateLimit, WithMaxTotalJobs and WithBudget, in that order.
+// The stages are consulted concurrently by Try and its variants.
+func WithAdmission(stages ...AdmissionStage) Option {
+    return func(jh *JobHandler) {
+        jh.stages = append(jh.stages, stages...)
+    }
+}
+
+// WithAdmissionPipeline makes the jobhandler admit jobs with the stages
+// returned by fn, which is passed the stages of WithAdmission followed by
+// the built-in stages, see WithAdmission, once all options are applied.
+// fn may reorder, wrap or drop the stages, e.g. to instrument the
+// admission decisions or to check a quota after the rate limit.
+// The built-in stages implement fmt.Stringer, returning "rate",
+// "total" and "budget".
+func WithAdmissionPipeline(fn func(stages []AdmissionStage) []AdmissionStage) Option {
+    return func(jh *JobHandler) {
+        jh.pipelineFn = fn
+    }
+}
+
+var (
+    errRateLimited = errors.New("rate limited")
+    errMaxTotal    = errors.New("max total jobs")
+    errBudget      = errors.New(reasonBudget)
+)
+
+// buildPipeline sets the admission stages of the jobhandler,
+// see WithAdmissionPipeline.
+func (jh *JobHandler) buildPipeline() {
+    stages := append([]AdmissionStage(nil), jh.stages...)
+    if jh.rate != nil {
+        stages = append(stages, rateStage{jh})
+    }
+    if jh.maxTotal > 0 {
+        stages = append(stages, totalStage{jh})
+    }
+    if jh.budget != nil {
+        stages = append(stages, budgetStage{jh})
+    }
+    if jh.pipelineFn != nil {
+        stages = jh.pipelineFn(stages)
+    }
+    jh.pipeline = stages
+}
+
+// admitStages runs the admission stages for a. If a stage rejects the jobs,
+// the stages before it are undone and its error is returned.
+func (jh *JobHandler) admitStages(a Admission) error {
+    for i, stage := range jh.pipeline {
+        if err := stage.Admit(a); err != nil && !a.Forced {
+            jh.undoStages(a, i)
+            return err
+        }
+    }
+    return nil
+}
+
+// undoStages undoes the first n admission stages for a, in reverse order.
+func (jh *JobHandler) undoStages(a Admission, n int) {
+    for i := n - 1; i >= 0; i-- {
+        jh.pipeline[i].Undo(a)
+    }
+}
+
+// releaseStages releases the jobs a from the admission stages.
+func (jh *JobHandler) releaseStages(a Admission) {
+    for _, stage := range jh.pipeline {
+        stage.Release(a)
+    }
+}
+
+// rateStage is the built-in stage of WithRateLimit.
+type rateStage struct {
+    jh *JobHandler
+}
+
+func (s rateStage) Admit(a Admission) error {
+    if a.Forced || s.jh.rate.take(s.jh.clock().Now(), a.Delta) {
+        return nil
+    }
+    return errRateLimited
+}
+
+func (s rateStage) Undo(a Admission) {
+    s.jh.rate.refund(a.Delta)
+}
+
+func (s rateStage) Release(Admission) {}
+
+func (s rateStage) String() string {
+    return "rate"
+}
+
+// totalStage is the built-in stage of WithMaxTotalJobs.
+type totalStage struct {
+    jh *JobHandler
+}
+
+func (s totalStage) Admit(a Admission) error {
+    if a.Forced || s.jh.reserveTotal(a.Delta) {
+        return nil
+    }
+    return errMaxTotal
+}
+
+func (s totalStage) Undo(a Admission) {
+    s.jh.unreserveTotal(a.Delta)
+}
+
+func (s totalStage) Release(Admission) {}
+
+func (s totalStage) String() string {
+    return "total"
+}
+
+// budgetStage is the built-in stage of WithBudget.
+type budgetStage struct {
+    jh *JobHandler
+}
+
+func (s budgetStage) Admit(a Admission) error {
+    if a.Forced {
+        s.jh.budget.force(int64(a.Delta))
+        return nil
+    }
+    if s.jh.budget.acquire(a.Delta) {
+        return nil
+    }
+    return errBudget
+}
+
+func (s budgetStage) Undo(a Admission) {
+    s.jh.budget.release(int64(a.Delta))
+}
+
+func (s budgetStage) Release(a Admission) {
+    s.jh.budget.release(int64(a.Delta))
+}
+
+func (s budgetStage) String() string {
+    return "budget"
+}
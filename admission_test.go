@@ -0,0 +1,120 @@
+package jobhandler
+import(
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "testing"
+)
+
+func TestWithAdmission(t *testing.T) {
+    killed := AdmissionFunc(func(a Admission) error {
+        if a.Class == "reindex" {
+            return errors.New("reindex disabled")
+        }
+        return nil
+    })
+    jh := New(context.Background(), WithAdmission(killed), WithRejectionLog(1))
+    if _, ok := jh.TryNamed("reindex"); ok {
+        t.Fatal("took a killed job")
+    }
+    if r := jh.Rejections(); len(r) != 1 || r[0].Reason != "reindex disabled" {
+        t.Fatal("unexpected rejections", r)
+    }
+    if !jh.TryFuncGroup("ingest", func (ctx context.Context) {}) || !jh.Try() {
+        t.Fatal("rejected an admitted job")
+    }
+    jh.Done()
+    jh.Stop()
+    jh.WaitAll()
+}
+
+// quota admits up to limit jobs per class.
+type quota struct {
+    mu    sync.Mutex
+    limit int
+    used  map[string]int
+}
+
+func (q *quota) Admit(a Admission) error {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    if !a.Forced && q.used[a.Class] + a.Delta > q.limit {
+        return errors.New("quota exceeded")
+    }
+    q.used[a.Class] += a.Delta
+    return nil
+}
+
+func (q *quota) Undo(a Admission) {
+    q.Release(a)
+}
+
+func (q *quota) Release(a Admission) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    q.used[a.Class] -= a.Delta
+}
+
+func TestAdmissionRelease(t *testing.T) {
+    q := &quota{limit: 2, used: make(map[string]int)}
+    jh := New(context.Background(), WithAdmission(q), WithBudget(NewBudget(1)))
+    j, ok := jh.TryNamed("reindex")
+    if !ok {
+        t.Fatal("unable to try")
+    }
+    if _, ok := jh.TryNamed("reindex"); ok {
+        t.Fatal("took a job beyond the budget")
+    }
+    if q.used["reindex"] != 1 {
+        t.Fatal("quota not undone after a later stage rejected", q.used)
+    }
+    j.Done()
+    if q.used["reindex"] != 0 {
+        t.Fatal("quota not released when done", q.used)
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func TestWithAdmissionPipeline(t *testing.T) {
+    var names []string
+    var admitted []string
+    jh := New(context.Background(), WithRateLimit(1, 1), WithBudget(NewBudget(1)),
+        WithAdmissionPipeline(func(stages []AdmissionStage) []AdmissionStage {
+            for i, stage := range stages {
+                name := fmt.Sprint(stage)
+                names = append(names, name)
+                stages[i] = instrumented{stage, func() { admitted = append(admitted, name) }}
+            }
+            // Check the budget before the rate
+            stages[0], stages[1] = stages[1], stages[0]
+            return stages
+        }))
+    if len(names) != 2 || names[0] != "rate" || names[1] != "budget" {
+        t.Fatal("unexpected built-in stages", names)
+    }
+    if !jh.Try() {
+        t.Fatal("unable to try")
+    }
+    if jh.Try() {
+        t.Fatal("took a job beyond the budget")
+    }
+    if len(admitted) != 3 || admitted[0] != "budget" || admitted[1] != "rate" || admitted[2] != "budget" {
+        t.Fatal("unexpected admission order", admitted)
+    }
+    jh.Done()
+    jh.Stop()
+    jh.WaitAll()
+}
+
+// instrumented calls observe before each Admit of its stage.
+type instrumented struct {
+    AdmissionStage
+    observe func()
+}
+
+func (s instrumented) Admit(a Admission) error {
+    s.observe()
+    return s.AdmissionStage.Admit(a)
+}
@@ -0,0 +1,62 @@
+package jobhandler
+
+import(
+    "sync/atomic"
+)
+
+// A Budget is a concurrency limit shared by several jobhandlers,
+// e.g. per-tenant children, so that the sum of their jobs never
+// exceeds the limit while each keeps its own lifecycle.
+// A job taken with TryN(delta) weighs delta.
+type Budget struct {
+    limit    int64
+    used     atomic.Int64
+    capacity notifier
+}
+
+// NewBudget creates a budget of limit concurrent jobs.
+func NewBudget(limit int64) *Budget {
+    return &Budget{limit: limit}
+}
+
+// WithBudget makes the jobhandler draw its jobs from b.
+// Jobs that would exceed b are rejected.
+func WithBudget(b *Budget) Option {
+    return func(jh *JobHandler) {
+        jh.budget = b
+    }
+}
+
+// InUse returns the number of jobs currently drawn from b.
+func (b *Budget) InUse() int64 {
+    return b.used.Load()
+}
+
+func (b *Budget) acquire(n int) bool {
+    if b == nil {
+        return true
+    }
+    for {
+        prev := b.used.Load()
+        if prev + int64(n) > b.limit {
+            return false
+        }
+        if b.used.CompareAndSwap(prev, prev + int64(n)) {
+            return true
+        }
+    }
+}
+
+// force draws n jobs from b regardless of its limit.
+func (b *Budget) force(n int64) {
+    if b != nil {
+        b.used.Add(n)
+    }
+}
+
+func (b *Budget) release(n int64) {
+    if b != nil {
+        b.used.Add(-n)
+        b.capacity.notify()
+    }
+}
@@ -0,0 +1,38 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+)
+
+func TestBudget(t *testing.T) {
+    b := NewBudget(3)
+    parent := New(context.Background())
+    a := parent.NewChild("a", WithBudget(b))
+    c := parent.NewChild("c", WithBudget(b))
+    if !a.TryN(2) || !c.Try() {
+        t.Fatal("failed to take jobs within budget")
+    }
+    if c.Try() {
+        t.Fatal("took a job beyond budget")
+    }
+    if n := b.InUse(); n != 3 {
+        t.Fatal("unexpected jobs in use", n)
+    }
+    a.Done()
+    if !c.Try() {
+        t.Fatal("failed to take a released job")
+    }
+    c.Stop()
+    c.Done()
+    c.Done()
+    a.ForceStop()
+    if n := b.InUse(); n != 0 {
+        t.Fatal("unexpected jobs in use", n)
+    }
+    a.Done()
+    if n := b.InUse(); n != 0 {
+        t.Fatal("late done released jobs", n)
+    }
+    parent.Stop()
+    parent.WaitAll()
+}
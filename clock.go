@@ -0,0 +1,59 @@
+package jobhandler
+
+import(
+    "time"
+)
+
+// A Clock is the source of time of a jobhandler, see WithClock.
+type Clock interface {
+    Now() time.Time
+    NewTimer(d time.Duration) Timer
+}
+
+// A Timer is a single event timer created by a Clock, see time.Timer.
+type Timer interface {
+    // C returns the channel on which the time is sent when the timer fires.
+    C() <-chan time.Time
+    // Stop prevents the timer from firing.
+    // Returns false if the timer already fired or was stopped.
+    Stop() bool
+}
+
+// WithClock makes the jobhandler use c for all time keeping,
+// e.g. to test time dependent logic without sleeping.
+// The default is the system clock.
+func WithClock(c Clock) Option {
+    return func(jh *JobHandler) {
+        jh.clk = c
+    }
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+    return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+    return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+    t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+    return t.t.C
+}
+
+func (t systemTimer) Stop() bool {
+    return t.t.Stop()
+}
+
+// clock returns the clock of the jobhandler.
+func (jh *JobHandler) clock() Clock {
+    if jh.clk == nil {
+        return systemClock{}
+    }
+    return jh.clk
+}
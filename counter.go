@@ -0,0 +1,32 @@
+package jobhandler
+
+import(
+    "math/rand/v2"
+    "sync/atomic"
+)
+
+// counterShards is the number of shards of a shardedCounter.
+const counterShards = 8
+
+// A shardedCounter is a counter spread over shards on their own cache
+// lines, so that goroutines adding to it concurrently rarely contend.
+type shardedCounter struct {
+    shards [counterShards]struct {
+        n atomic.Uint64
+        _ [56]byte
+    }
+}
+
+// Add adds delta to a random shard of c.
+func (c *shardedCounter) Add(delta uint64) {
+    c.shards[rand.Uint32() % counterShards].n.Add(delta)
+}
+
+// Load returns the sum of the shards of c.
+func (c *shardedCounter) Load() uint64 {
+    var n uint64
+    for i := range c.shards {
+        n += c.shards[i].n.Load()
+    }
+    return n
+}
@@ -0,0 +1,38 @@
+//go:build cgo
+
+package cstop
+
+/*
+typedef void (*jobhandler_stop_fn)(void *userdata);
+
+static void jobhandler_call_stop_fn(void *fn, void *userdata) {
+    ((jobhandler_stop_fn)fn)(userdata);
+}
+*/
+import "C"
+
+import(
+    "github.com/cblach/jobhandler"
+    "unsafe"
+)
+
+// OnStop registers the C function pointer fn, of type
+//
+//     void (*)(void *userdata)
+//
+// to be called with userdata when jh is stopped.
+// The notification counts as a job of jh until fn returns,
+// so jh.WaitAll does not return before the C component has been told to stop.
+// If jh is already stopped, fn is called immediately and OnStop returns false.
+func OnStop(jh *jobhandler.JobHandler, fn, userdata unsafe.Pointer) bool {
+    if !jh.Try() {
+        C.jobhandler_call_stop_fn(fn, userdata)
+        return false
+    }
+    go func() {
+        defer jh.Done()
+        <-jh.OnStop()
+        C.jobhandler_call_stop_fn(fn, userdata)
+    }()
+    return true
+}
@@ -0,0 +1,33 @@
+//go:build cgo
+
+package cstop
+import(
+    "context"
+    "github.com/cblach/jobhandler"
+    "github.com/cblach/jobhandler/cstop/internal/cstoptest"
+    "testing"
+)
+
+func TestOnStop(t *testing.T) {
+    jh := jobhandler.New(context.Background())
+    if !OnStop(jh, cstoptest.Fn(), cstoptest.Token()) {
+        t.Fatal("unable to register the stop function")
+    }
+    if n := cstoptest.Stops(); n != 0 {
+        t.Fatal("stop function called while running", n)
+    }
+    jh.Stop()
+    jh.WaitAll()
+    if n := cstoptest.Stops(); n != 1 {
+        t.Fatal("stop function not called before WaitAll returned", n)
+    }
+    if cstoptest.Userdata() != cstoptest.Token() {
+        t.Fatal("stop function called with the wrong userdata")
+    }
+    if OnStop(jh, cstoptest.Fn(), cstoptest.Token()) {
+        t.Fatal("registered on a stopped jobhandler")
+    }
+    if n := cstoptest.Stops(); n != 2 {
+        t.Fatal("stop function not called immediately", n)
+    }
+}
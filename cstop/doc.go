@@ -0,0 +1,5 @@
+// Package cstop notifies C components when a jobhandler is stopped.
+//
+// It is meant for processes embedding C libraries that must be told to
+// wind down before Go cleanup runs. The package requires cgo.
+package cstop
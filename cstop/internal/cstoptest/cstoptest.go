@@ -0,0 +1,59 @@
+//go:build cgo
+
+// Package cstoptest provides a C stop function for the tests of cstop,
+// as test files cannot use cgo.
+package cstoptest
+
+/*
+#include <stdatomic.h>
+
+static atomic_int stops;
+static void *_Atomic last;
+static int token;
+
+static void record_stop(void *userdata) {
+    atomic_store(&last, userdata);
+    atomic_fetch_add(&stops, 1);
+}
+
+static void *record_stop_fn(void) {
+    return (void *)record_stop;
+}
+
+static void *token_ptr(void) {
+    return &token;
+}
+
+static int stop_count(void) {
+    return atomic_load(&stops);
+}
+
+static void *last_userdata(void) {
+    return atomic_load(&last);
+}
+*/
+import "C"
+
+import(
+    "unsafe"
+)
+
+// Fn returns a pointer to a C stop function that records its calls.
+func Fn() unsafe.Pointer {
+    return C.record_stop_fn()
+}
+
+// Token returns a C pointer to pass as userdata to Fn.
+func Token() unsafe.Pointer {
+    return C.token_ptr()
+}
+
+// Stops returns the number of calls of Fn.
+func Stops() int {
+    return int(C.stop_count())
+}
+
+// Userdata returns the userdata of the last call of Fn.
+func Userdata() unsafe.Pointer {
+    return C.last_userdata()
+}
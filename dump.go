@@ -0,0 +1,108 @@
+package jobhandler
+
+import(
+    "fmt"
+    "io"
+    "os"
+    "os/signal"
+    "slices"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// Dump writes a human readable dump of the state of the jobhandler to w:
+// its name, state, number of outstanding jobs, counters,
+// running groups, jobs taken with TryJob and TryNamed, blocked waiters
+// and recent rejections. Goroutine stacks are not included, as the
+// jobhandler does not record where jobs run; use the SIGQUIT dump of the
+// Go runtime or runtime/pprof for those.
+func (jh *JobHandler) Dump(w io.Writer) error {
+    name := jh.name
+    if name == "" {
+        name = "(unnamed)"
+    }
+    stats := jh.Stats()
+    _, err := fmt.Fprintf(w, "jobhandler %s: %s, %d jobs outstanding\n", name, jh.State(), jh.inFlight())
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintf(w, "\texpired: %d, skipped: %d, late done: %d, dropped: %d\n",
+        stats.Expired, stats.Skipped, stats.LateDone, stats.Dropped)
+    if err != nil {
+        return err
+    }
+    jh.mu.Lock()
+    groups := make([]string, 0, len(jh.groups))
+    counts := make(map[string]int, len(jh.groups))
+    for name, g := range jh.groups {
+        groups = append(groups, name)
+        counts[name] = len(g.cancels)
+    }
+    jh.mu.Unlock()
+    slices.Sort(groups)
+    for _, g := range groups {
+        if _, err := fmt.Fprintf(w, "\tgroup %q: %d jobs running\n", g, counts[g]); err != nil {
+            return err
+        }
+    }
+    for _, j := range jh.Jobs() {
+        name := j.Name
+        if name == "" {
+            name = "(unnamed)"
+        }
+        if _, err := fmt.Fprintf(w, "\tjob %s: running for %s\n", name, j.Elapsed); err != nil {
+            return err
+        }
+    }
+    for _, wt := range jh.Waiters() {
+        if _, err := fmt.Fprintf(w, "\twaiter %s: waiting for %s\n", wt.Method, wt.Elapsed); err != nil {
+            return err
+        }
+    }
+    for _, r := range jh.Rejections() {
+        _, err := fmt.Fprintf(w, "\trejected %s: %s at %s (%s:%d)\n",
+            r.Time.Format(time.RFC3339Nano), r.Reason, r.Function, r.File, r.Line)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// DumpOnSignal makes the jobhandler write its state dump to stderr,
+// see Dump, whenever the process receives one of the signals sigs,
+// mirroring the SIGQUIT behavior of the Go runtime but scoped to job state.
+// Call the returned function to stop dumping on the signals;
+// calling it more than once has no further effect.
+func (jh *JobHandler) DumpOnSignal(sigs ...os.Signal) (stop func()) {
+    ch := make(chan os.Signal, 1)
+    done := make(chan struct{})
+    signal.Notify(ch, sigs...)
+    go func() {
+        for {
+            select {
+            case <-ch:
+                jh.Dump(stderr)
+            case <-done:
+                return
+            }
+        }
+    }()
+    var once sync.Once
+    return func() {
+        once.Do(func() {
+            signal.Stop(ch)
+            close(done)
+        })
+    }
+}
+
+// inFlight returns the number of jobs that are not done.
+func (jh *JobHandler) inFlight() int64 {
+    n := atomic.LoadInt64(&jh.n) &^ runningBit
+    if n < 0 || n >= lateBit {
+        return 0
+    }
+    return n
+}
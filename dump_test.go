@@ -0,0 +1,35 @@
+package jobhandler
+import(
+    "context"
+    "strings"
+    "testing"
+)
+
+func TestDump(t *testing.T) {
+    jh := New(context.Background(), WithName("server"), WithRejectionLog(1))
+    started := make(chan struct{})
+    release := make(chan struct{})
+    go jh.TryFuncGroup("tenant42", func (ctx context.Context) {
+        close(started)
+        <-release
+    })
+    <-started
+    jh.Stop()
+    jh.Try()
+    var sb strings.Builder
+    if err := jh.Dump(&sb); err != nil {
+        t.Fatal("unexpected error", err)
+    }
+    for _, s := range []string{
+        "jobhandler server: draining, 1 jobs outstanding",
+        "late done: 0, dropped: 0",
+        `group "tenant42": 1 jobs running`,
+        "rejected",
+    } {
+        if !strings.Contains(sb.String(), s) {
+            t.Fatalf("dump does not contain %q:\n%s", s, sb.String())
+        }
+    }
+    close(release)
+    jh.WaitAll()
+}
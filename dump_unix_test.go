@@ -0,0 +1,32 @@
+//go:build unix
+
+package jobhandler
+import(
+    "bufio"
+    "context"
+    "io"
+    "strings"
+    "syscall"
+    "testing"
+)
+
+func TestDumpOnSignal(t *testing.T) {
+    r, w := io.Pipe()
+    defer func(w io.Writer) {
+        stderr = w
+    }(stderr)
+    stderr = w
+    jh := New(context.Background(), WithName("worker"))
+    stop := jh.DumpOnSignal(syscall.SIGUSR1)
+    syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+    line, err := bufio.NewReader(r).ReadString('\n')
+    if err != nil || !strings.HasPrefix(line, "jobhandler worker: running") {
+        t.Fatal("unexpected dump", line, err)
+    }
+    stop()
+    stop()
+    // Unblock the rest of the dump
+    r.Close()
+    jh.Stop()
+    jh.WaitAll()
+}
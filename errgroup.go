@@ -0,0 +1,62 @@
+package jobhandler
+
+import(
+    "errors"
+    "sync"
+)
+
+// An ErrGroup collects the errors of a set of jobs, in the style of
+// golang.org/x/sync/errgroup. Unlike the named groups of TryFuncGroup,
+// an ErrGroup is not cancellable and only exists to wait for its jobs.
+type ErrGroup struct {
+    jh   *JobHandler
+    wg   sync.WaitGroup
+    mu   sync.Mutex
+    errs []error
+}
+
+// NewErrGroup creates an ErrGroup running jobs of jh.
+func (jh *JobHandler) NewErrGroup() *ErrGroup {
+    return &ErrGroup{jh: jh}
+}
+
+// Go takes on a single job and runs fn asynchronously,
+// recording the error returned by fn, if any.
+// A panicking fn is recovered and its panic recorded as a *PanicError.
+// Returns false if the jobhandler is stopped, in which case fn is not run.
+// Do not call Done(), the job is automatically flagged as done after fn exits.
+func (g *ErrGroup) Go(fn func() error) bool {
+    if !g.jh.Try() {
+        return false
+    }
+    g.wg.Add(1)
+    go func() {
+        defer g.jh.Done()
+        defer g.wg.Done()
+        var err error
+        g.jh.runJob("", func() {
+            err = call(fn)
+        })
+        if err != nil {
+            g.mu.Lock()
+            g.errs = append(g.errs, err)
+            g.mu.Unlock()
+        }
+    }()
+    return true
+}
+
+func call(fn func() error) (err error) {
+    defer recoverError(&err)
+    return fn()
+}
+
+// Wait waits for all jobs started by Go to exit and returns their
+// errors joined in the order they were returned, see errors.Join,
+// or nil if no job failed.
+func (g *ErrGroup) Wait() error {
+    g.wg.Wait()
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    return errors.Join(g.errs...)
+}
@@ -0,0 +1,28 @@
+package jobhandler
+import(
+    "context"
+    "errors"
+    "testing"
+)
+
+func TestErrGroup(t *testing.T) {
+    jh := New(context.Background())
+    g := jh.NewErrGroup()
+    errA, errB := errors.New("a"), errors.New("b")
+    g.Go(func() error { return errA })
+    g.Go(func() error { return nil })
+    g.Go(func() error { panic(errB) })
+    err := g.Wait()
+    var perr *PanicError
+    if !errors.Is(err, errA) || !errors.Is(err, errB) || !errors.As(err, &perr) {
+        t.Fatal("unexpected error", err)
+    }
+    if err := jh.NewErrGroup().Wait(); err != nil {
+        t.Fatal("unexpected error", err)
+    }
+    jh.Stop()
+    if g.Go(func() error { return nil }) {
+        t.Fatal("took a job after stop")
+    }
+    jh.WaitAll()
+}
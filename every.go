@@ -0,0 +1,137 @@
+package jobhandler
+
+import(
+    "math/rand"
+    "time"
+)
+
+// WithJitter makes TryEvery, TryAfter and TryAt delay each run of their
+// function by a random duration in [0, d), e.g. to keep a fleet of
+// processes from running periodic jobs in lockstep.
+func WithJitter(d time.Duration) JobOption {
+    return func(cfg *jobConfig) {
+        cfg.jitter = d
+    }
+}
+
+// WithFixedRate makes TryEvery run its function at a fixed rate,
+// interval after the previous run started, instead of interval after
+// the previous run returned. Runs that would start while the previous run
+// is still running are skipped.
+func WithFixedRate() JobOption {
+    return func(cfg *jobConfig) {
+        cfg.fixedRate = true
+    }
+}
+
+// WithWakeName makes TryEvery and TryAfter wait with TrySleepNamed under
+// name, so Wake(name) starts the next run of their function at once,
+// e.g. to run a poll now. With WithFixedRate, a woken run takes the
+// place of the run it was waiting for.
+func WithWakeName(name string) JobOption {
+    return func(cfg *jobConfig) {
+        cfg.wakeName = name
+    }
+}
+
+// WithJobClass makes TryEvery, TryAfter and TryAt run their function as
+// a job of class, so that a run is skipped while a gate of the class is
+// closed, see Gate, e.g. to run scheduled jobs only on the leader.
+// Runs skipped by TryAfter and TryAt are counted as skipped in Stats.
+func WithJobClass(class string) JobOption {
+    return func(cfg *jobConfig) {
+        cfg.class = class
+    }
+}
+
+// gatedRun returns true if a gate of the class of cfg is closed.
+func (jh *JobHandler) gatedRun(cfg *jobConfig) bool {
+    return cfg.class != "" && jh.gated(cfg.class)
+}
+
+// sleep sleeps d like TrySleep, or like TrySleepNamed if cfg has a wake name.
+func (jh *JobHandler) sleep(cfg *jobConfig, d time.Duration) bool {
+    if cfg.wakeName != "" {
+        return jh.TrySleepNamed(cfg.wakeName, d)
+    }
+    return jh.TrySleep(d)
+}
+
+// jitterDelay returns a random delay in [0, cfg.jitter).
+func (cfg *jobConfig) jitterDelay() time.Duration {
+    if cfg.jitter <= 0 {
+        return 0
+    }
+    return time.Duration(rand.Int63n(int64(cfg.jitter)))
+}
+
+// TryEvery runs fn every interval as a single tracked job until the
+// jobhandler is stopped, e.g. for a background ticker. The first run is
+// interval after TryEvery is called. By default runs are interval after
+// the previous run returned, see WithFixedRate and WithJitter,
+// and WithWakeName to start a run early with Wake. Runs are skipped
+// while a gate of its class is closed, see WithJobClass.
+// A run that has started is not interrupted when the jobhandler is stopped.
+// Returns true if the job is successfully taken
+// and false if the JobHandler is stopped.
+// TryEvery panics if interval is not positive.
+func (jh *JobHandler) TryEvery(interval time.Duration, fn func(), opts ...JobOption) bool {
+    if interval <= 0 {
+        jh.panic("TryEvery with non-positive interval")
+    }
+    if !jh.Try() {
+        return false
+    }
+    cfg := newJobConfig(opts)
+    go func() {
+        defer jh.Done()
+        defer cfg.lock()()
+        next := jh.clock().Now().Add(interval)
+        for jh.sleep(&cfg, next.Sub(jh.clock().Now()) + cfg.jitterDelay()) {
+            if !jh.gatedRun(&cfg) {
+                jh.runJob("", fn)
+            }
+            now := jh.clock().Now()
+            if !cfg.fixedRate {
+                next = now.Add(interval)
+                continue
+            }
+            // Skip the runs missed while fn was running
+            next = next.Add(interval)
+            for next.Before(now) {
+                next = next.Add(interval)
+            }
+        }
+    }()
+    return true
+}
+
+// TryAfter runs fn as a tracked job d from now, unless the jobhandler is
+// stopped first, in which case fn is never run, the job is flagged as done
+// and counted as skipped in Stats. The same applies if a gate of its class
+// is closed when fn is due, see WithJobClass.
+// Returns true if the job is successfully taken
+// and false if the JobHandler is stopped.
+// Do not call Done(), the job is automatically
+// flagged as done after fn exits.
+func (jh *JobHandler) TryAfter(d time.Duration, fn func(), opts ...JobOption) bool {
+    if !jh.Try() {
+        return false
+    }
+    cfg := newJobConfig(opts)
+    go func() {
+        if !jh.sleep(&cfg, d + cfg.jitterDelay()) || jh.gatedRun(&cfg) {
+            jh.skip(1, nil)
+            return
+        }
+        defer cfg.lock()()
+        jh.runJob("", fn)
+        jh.Done()
+    }()
+    return true
+}
+
+// TryAt is like TryAfter, but runs fn at t.
+func (jh *JobHandler) TryAt(t time.Time, fn func(), opts ...JobOption) bool {
+    return jh.TryAfter(t.Sub(jh.clock().Now()), fn, opts...)
+}
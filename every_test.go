@@ -0,0 +1,151 @@
+package jobhandler_test
+import(
+    "context"
+    "github.com/cblach/jobhandler"
+    "github.com/cblach/jobhandler/jobhandlertest"
+    "testing"
+    "time"
+)
+
+// blockUntilTimers is clk.BlockUntilTimers(n), but fails the test
+// if the timers are not waiting within a second, e.g. when a job that
+// is behind its schedule runs without sleeping.
+func blockUntilTimers(t *testing.T, clk *jobhandlertest.FakeClock, n int) {
+    t.Helper()
+    done := make(chan struct{})
+    go func() {
+        clk.BlockUntilTimers(n)
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+        t.Fatal("expected", n, "timers")
+    }
+}
+
+// nextRun advances clk a second at a time until the TryEvery job
+// reporting on runs has run, and returns the time the run started.
+func nextRun(t *testing.T, clk *jobhandlertest.FakeClock, runs chan time.Time) time.Time {
+    t.Helper()
+    for i := 0; i < 60; i++ {
+        blockUntilTimers(t, clk, 1)
+        clk.Advance(time.Second)
+        // A run that is due has returned once the next sleep has started
+        blockUntilTimers(t, clk, 1)
+        select {
+        case at := <-runs:
+            return at
+        default:
+        }
+    }
+    t.Fatal("no run within a minute")
+    return time.Time{}
+}
+
+func TestTryEvery(t *testing.T) {
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    for _, tc := range []struct {
+        name string
+        work time.Duration
+        opts []jobhandler.JobOption
+        want []time.Duration
+    }{
+        {"fixed delay", 3 * time.Second, nil, []time.Duration{10, 23, 36}},
+        {"fixed rate", 3 * time.Second, []jobhandler.JobOption{jobhandler.WithFixedRate()}, []time.Duration{10, 20, 30}},
+        {"skip missed ticks", 25 * time.Second, []jobhandler.JobOption{jobhandler.WithFixedRate()}, []time.Duration{10, 40, 70}},
+    } {
+        t.Run(tc.name, func (t *testing.T) {
+            clk := jobhandlertest.NewFakeClock(start)
+            jh := jobhandler.New(context.Background(), jobhandler.WithClock(clk))
+            runs := make(chan time.Time, 1)
+            if !jh.TryEvery(10 * time.Second, func() {
+                at := clk.Now()
+                clk.Advance(tc.work)
+                runs <- at
+            }, tc.opts...) {
+                t.Fatal("unable to try")
+            }
+            for i, want := range tc.want {
+                if at := nextRun(t, clk, runs); !at.Equal(start.Add(want * time.Second)) {
+                    t.Fatalf("run %d at %v, expected %v", i, at.Sub(start), want * time.Second)
+                }
+            }
+            jh.Stop()
+            jh.WaitAll()
+            if jh.TryEvery(time.Second, func() {}) {
+                t.Fatal("took a job after stop")
+            }
+        })
+    }
+}
+
+func TestTryEveryJitter(t *testing.T) {
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    clk := jobhandlertest.NewFakeClock(start)
+    jh := jobhandler.New(context.Background(), jobhandler.WithClock(clk))
+    runs := make(chan time.Time, 1)
+    if !jh.TryEvery(10 * time.Second, func() { runs <- clk.Now() }, jobhandler.WithJitter(5 * time.Second)) {
+        t.Fatal("unable to try")
+    }
+    prev := start
+    for i := 0; i < 20; i++ {
+        // Runs are seen at whole seconds, so the bounds are inclusive
+        at := nextRun(t, clk, runs)
+        if d := at.Sub(prev); d < 10 * time.Second || d > 15 * time.Second {
+            t.Fatalf("run %d after %v, expected 10s to 15s", i, d)
+        }
+        prev = at
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func TestTryAfter(t *testing.T) {
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    clk := jobhandlertest.NewFakeClock(start)
+    jh := jobhandler.New(context.Background(), jobhandler.WithClock(clk))
+    ran := make(chan time.Time, 1)
+    if !jh.TryAt(start.Add(10 * time.Second), func() { ran <- clk.Now() }) {
+        t.Fatal("unable to try")
+    }
+    late := make(chan struct{}, 1)
+    if !jh.TryAfter(time.Hour, func() { late <- struct{}{} }) {
+        t.Fatal("unable to try")
+    }
+    blockUntilTimers(t, clk, 2)
+    clk.Advance(10 * time.Second - time.Nanosecond)
+    // Both jobs are still sleeping
+    blockUntilTimers(t, clk, 2)
+    clk.Advance(time.Nanosecond)
+    if at := <-ran; !at.Equal(start.Add(10 * time.Second)) {
+        t.Fatal("ran at", at.Sub(start), "expected 10s")
+    }
+    jh.Stop()
+    jh.WaitAll()
+    if len(late) != 0 {
+        t.Fatal("delayed job ran after stop")
+    }
+    if n := jh.Stats().Skipped; n != 1 {
+        t.Fatal("expected 1 skipped job, got", n)
+    }
+    if jh.TryAfter(0, func() {}) {
+        t.Fatal("took a job after stop")
+    }
+}
+
+func TestTryEveryWake(t *testing.T) {
+    clk := jobhandlertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+    jh := jobhandler.New(context.Background(), jobhandler.WithClock(clk))
+    ran := make(chan struct{}, 1)
+    if !jh.TryEvery(time.Hour, func() { ran <- struct{}{} }, jobhandler.WithWakeName("poll")) {
+        t.Fatal("unable to try")
+    }
+    blockUntilTimers(t, clk, 1)
+    if jh.Wake("poll") == 0 {
+        t.Fatal("TryEvery is not sleeping under its wake name")
+    }
+    <-ran
+    jh.Stop()
+    jh.WaitAll()
+}
@@ -91,3 +91,19 @@ func ExampleJobHandler_TryNFuncAsync() {
     fmt.Print("prime sum for 1..100 is ", sum.Load())
     // Output: prime sum for 1..100 is 1060
 }
+
+func ExampleGather() {
+    jh := jobhandler.New(context.Background())
+    res, err := jobhandler.Gather(jh, 0,
+        func (ctx context.Context) (string, error) { return "users", nil },
+        func (ctx context.Context) (string, error) { return "orders", nil },
+        func (ctx context.Context) (string, error) { return "stock", nil },
+    )
+    if err != nil {
+        fmt.Println("failed to gather:", err)
+    }
+    fmt.Println(res)
+    jh.Stop()
+    jh.WaitAll()
+    // Output: [users orders stock]
+}
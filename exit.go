@@ -0,0 +1,40 @@
+package jobhandler
+
+import(
+    "fmt"
+    "io"
+    "os"
+    "slices"
+    "time"
+)
+
+// exit and stderr, also used by DumpOnSignal, are replaced by tests.
+var (
+    exit             = os.Exit
+    stderr io.Writer = os.Stderr
+)
+
+// ExitAfterDrain stops the jobhandler, waits up to grace for all jobs to be
+// done, see StopWithTimeout, and its AfterDrain hooks to complete, and exits
+// the process with code, see os.Exit. If jobs were abandoned, a summary of
+// them is printed to stderr before exiting. ExitAfterDrain does not return.
+func (jh *JobHandler) ExitAfterDrain(code int, grace time.Duration) {
+    jh.StopWithTimeout(grace)
+    jh.WaitStopped()
+    if s := jh.summary(); s.Abandoned > 0 {
+        name := s.Name
+        if name == "" {
+            name = "(unnamed)"
+        }
+        fmt.Fprintf(stderr, "jobhandler %s: abandoned %d jobs after %s\n", name, s.Abandoned, grace)
+        groups := make([]string, 0, len(s.AbandonedGroups))
+        for g := range s.AbandonedGroups {
+            groups = append(groups, g)
+        }
+        slices.Sort(groups)
+        for _, g := range groups {
+            fmt.Fprintf(stderr, "\tgroup %q: %d jobs\n", g, s.AbandonedGroups[g])
+        }
+    }
+    exit(code)
+}
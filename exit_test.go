@@ -0,0 +1,31 @@
+package jobhandler
+import(
+    "context"
+    "io"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestExitAfterDrain(t *testing.T) {
+    var sb strings.Builder
+    code := -1
+    defer func(w io.Writer, fn func(int)) {
+        stderr, exit = w, fn
+    }(stderr, exit)
+    stderr, exit = &sb, func(c int) { code = c }
+    jh := New(context.Background(), WithName("cli"))
+    started := make(chan struct{})
+    go jh.TryFuncGroup("upload", func (ctx context.Context) {
+        close(started)
+        <-jh.ForceContext().Done()
+    })
+    <-started
+    jh.ExitAfterDrain(3, time.Millisecond)
+    if code != 3 {
+        t.Fatal("unexpected exit code", code)
+    }
+    if s := sb.String(); !strings.Contains(s, "jobhandler cli: abandoned 1 jobs") || !strings.Contains(s, `group "upload": 1 jobs`) {
+        t.Fatalf("unexpected summary:\n%s", s)
+    }
+}
@@ -0,0 +1,101 @@
+package jobhandler
+
+import(
+    "context"
+    "errors"
+    "sync/atomic"
+    "time"
+)
+
+// ErrForceStopped is the cancellation cause of the context returned by
+// ForceContext once the jobhandler is force stopped.
+var ErrForceStopped = errors.New("jobhandler: force stopped")
+
+// ForceStop stops the jobhandler, if not already stopped, and abandons
+// all jobs that are not done: WaitAll returns without waiting for them,
+// and the context returned by ForceContext is cancelled so the abandoned
+// jobs can give up. Done calls for abandoned jobs are ignored and counted,
+// see Stats.LateDone.
+// Returns true if any jobs were abandoned.
+func (jh *JobHandler) ForceStop() bool {
+    if jh.forceCancel == nil {
+        return false
+    }
+    jh.stop(ErrForceStopped)
+    if !jh.forced.CompareAndSwap(false, true) {
+        return false
+    }
+    jh.forceCancel(ErrForceStopped)
+    for {
+        prev := atomic.LoadInt64(&jh.n)
+        if prev <= 0 || prev >= lateBit {
+            return false
+        }
+        if atomic.CompareAndSwapInt64(&jh.n, prev, drainedBit) {
+            jh.mu.Lock()
+            jh.abandoned = prev
+            jh.abandonedGroups = make(map[string]int, len(jh.groups))
+            for name, g := range jh.groups {
+                jh.abandonedGroups[name] = len(g.cancels)
+            }
+            jh.mu.Unlock()
+            jh.releaseStages(Admission{Delta: int(prev)})
+            jh.drained()
+            return true
+        }
+    }
+}
+
+// StopWithTimeout stops the jobhandler and waits up to d for all jobs to be done,
+// like WaitDrained. If jobs are still outstanding after d, the jobhandler
+// is force stopped, see ForceStop. AfterDrain hooks still running after d
+// do not force a stop, use WaitStopped to wait for them.
+// Returns true if all jobs were done within d and false if
+// jobs were abandoned.
+// The deadline is exposed through GraceDeadline while waiting.
+func (jh *JobHandler) StopWithTimeout(d time.Duration) bool {
+    jh.mu.Lock()
+    jh.graceDeadline = jh.clock().Now().Add(d)
+    jh.mu.Unlock()
+    jh.Stop()
+    if jh.waitDrainedTimeout(d) || jh.isDrained.Load() {
+        return true
+    }
+    return !jh.ForceStop()
+}
+
+// waitDrainedTimeout is WaitDrained, giving up after d.
+// Returns true if the jobhandler drained within d.
+func (jh *JobHandler) waitDrainedTimeout(d time.Duration) bool {
+    if jh.drainedChan == nil {
+        return true
+    }
+    defer jh.waitFor("StopWithTimeout")()
+    t := jh.clock().NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-jh.drainedChan:
+        return true
+    case <-t.C():
+        return false
+    }
+}
+
+// ForceContext returns a context that is cancelled when the jobhandler is
+// force stopped, with cause ErrForceStopped. Unlike the job contexts that are
+// cancelled on Stop, it lets jobs run through a graceful drain and only
+// abandons stragglers at the deadline of StopWithTimeout.
+func (jh *JobHandler) ForceContext() context.Context {
+    if jh.forceCtx == nil {
+        return canceledCtx
+    }
+    return jh.forceCtx
+}
+
+// GraceDeadline returns the time at which outstanding jobs are abandoned,
+// as set by StopWithTimeout. Returns false if no deadline is set.
+func (jh *JobHandler) GraceDeadline() (time.Time, bool) {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    return jh.graceDeadline, !jh.graceDeadline.IsZero()
+}
@@ -0,0 +1,70 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+func TestStopWithTimeout(t *testing.T) {
+    t.Run("drained", func (t *testing.T) {
+        jh := New(context.Background())
+        if !jh.Try() {
+            t.Fatal("unable to try")
+        }
+        go func () {
+            time.Sleep(time.Millisecond)
+            jh.Done()
+        }()
+        if !jh.StopWithTimeout(time.Second) {
+            t.Fatal("should drain in time")
+        }
+        if jh.ForceContext().Err() != nil {
+            t.Fatal("force context should not be cancelled")
+        }
+    })
+    t.Run("forced", func (t *testing.T) {
+        jh := New(context.Background())
+        if !jh.Try() {
+            t.Fatal("unable to try")
+        }
+        if jh.StopWithTimeout(time.Millisecond) {
+            t.Fatal("should not drain in time")
+        }
+        jh.WaitAll()
+        if err := context.Cause(jh.ForceContext()); err != ErrForceStopped {
+            t.Fatal("unexpected cause", err)
+        }
+        jh.Done()
+        if n := jh.Stats().LateDone; n != 1 {
+            t.Fatal("unexpected late dones", n)
+        }
+        if jh.ForceStop() {
+            t.Fatal("should already be force stopped")
+        }
+    })
+    t.Run("slow hook", func (t *testing.T) {
+        jh := New(context.Background())
+        release := make(chan struct{})
+        jh.AfterDrain(func (Summary) { <-release })
+        if !jh.StopWithTimeout(time.Millisecond) {
+            t.Fatal("slow hook forced a stop without jobs")
+        }
+        if jh.ForceContext().Err() != nil {
+            t.Fatal("force context should not be cancelled")
+        }
+        close(release)
+        jh.WaitAll()
+        jh.Reset()
+        jh.Stop()
+        jh.WaitAll()
+    })
+    t.Run("zero jobhandler", func (t *testing.T) {
+        var jh JobHandler
+        if jh.ForceStop() {
+            t.Fatal("nothing to abandon")
+        }
+        if !jh.StopWithTimeout(time.Millisecond) {
+            t.Fatal("zero jobhandler is drained")
+        }
+    })
+}
@@ -0,0 +1,56 @@
+package jobhandler
+
+// A Future is the pending result of a job started by TryResult.
+type Future[T any] struct {
+    done chan struct{}
+    val  T
+    err  error
+}
+
+// TryResult takes on a single job and runs fn asynchronously.
+// The returned future holds the result of fn once it exits.
+// A panicking fn is recovered and its panic returned as a *PanicError.
+// Returns false if the jobhandler is stopped, in which case fn is not run.
+// Do not call Done(), the job is automatically flagged as done after fn exits.
+func TryResult[T any](jh *JobHandler, fn func() (T, error)) (*Future[T], bool) {
+    if !jh.Try() {
+        return nil, false
+    }
+    f := &Future[T]{done: make(chan struct{})}
+    go func() {
+        defer jh.Done()
+        defer close(f.done)
+        jh.runJob("", func() {
+            f.val, f.err = result(fn)
+        })
+    }()
+    return f, true
+}
+
+func result[T any](fn func() (T, error)) (v T, err error) {
+    defer recoverError(&err)
+    return fn()
+}
+
+// Wait waits for the job to exit and returns its result.
+func (f *Future[T]) Wait() (T, error) {
+    <-f.done
+    return f.val, f.err
+}
+
+// Result returns the result of the job without waiting.
+// Returns false if the job has not exited yet.
+func (f *Future[T]) Result() (T, bool, error) {
+    select {
+    case <-f.done:
+        return f.val, true, f.err
+    default:
+        var zero T
+        return zero, false, nil
+    }
+}
+
+// Done returns a channel that is closed when the job exits.
+func (f *Future[T]) Done() <-chan struct{} {
+    return f.done
+}
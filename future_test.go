@@ -0,0 +1,41 @@
+package jobhandler
+import(
+    "context"
+    "errors"
+    "testing"
+)
+
+func TestTryResult(t *testing.T) {
+    jh := New(context.Background())
+    release := make(chan struct{})
+    f, ok := TryResult(jh, func() (int, error) {
+        <-release
+        return 42, nil
+    })
+    if !ok {
+        t.Fatal("failed to take job")
+    }
+    if _, ok, _ := f.Result(); ok {
+        t.Fatal("result before the job exited")
+    }
+    close(release)
+    if v, err := f.Wait(); v != 42 || err != nil {
+        t.Fatal("unexpected result", v, err)
+    }
+    if v, ok, err := f.Result(); v != 42 || err != nil || !ok {
+        t.Fatal("unexpected result", v, err, ok)
+    }
+    errTest := errors.New("test")
+    f, _ = TryResult(jh, func() (int, error) {
+        panic(errTest)
+    })
+    var perr *PanicError
+    if _, err := f.Wait(); !errors.As(err, &perr) || !errors.Is(err, errTest) {
+        t.Fatal("unexpected error", err)
+    }
+    jh.Stop()
+    if _, ok := TryResult(jh, func() (int, error) { return 0, nil }); ok {
+        t.Fatal("took a job after stop")
+    }
+    jh.WaitAll()
+}
@@ -0,0 +1,55 @@
+package jobhandler
+
+import(
+    "slices"
+    "sync/atomic"
+)
+
+// A Gate admits the jobs of a set of job classes only while it is open,
+// e.g. scheduled jobs that should only run on the leader of an election,
+// while the process otherwise remains live.
+type Gate struct {
+    classes []string
+    open    atomic.Bool
+}
+
+// Gate creates a gate for the job classes classes, which are
+// the groups of TryFuncGroup, the names of TryNamed and the classes
+// of scheduled jobs, see WithJobClass.
+// The gate starts closed. While a gate of a class is closed,
+// jobs of the class are rejected.
+func (jh *JobHandler) Gate(classes ...string) *Gate {
+    g := &Gate{classes: slices.Clone(classes)}
+    jh.mu.Lock()
+    jh.gates = append(jh.gates, g)
+    jh.mu.Unlock()
+    return g
+}
+
+// Open opens the gate, admitting jobs of its classes.
+func (g *Gate) Open() {
+    g.open.Store(true)
+}
+
+// Close closes the gate, rejecting new jobs of its classes.
+// Jobs that are already running are not affected.
+func (g *Gate) Close() {
+    g.open.Store(false)
+}
+
+// IsOpen returns true if the gate is open.
+func (g *Gate) IsOpen() bool {
+    return g.open.Load()
+}
+
+// gated returns true if a gate of class is closed.
+func (jh *JobHandler) gated(class string) bool {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    for _, g := range jh.gates {
+        if !g.IsOpen() && slices.Contains(g.classes, class) {
+            return true
+        }
+    }
+    return false
+}
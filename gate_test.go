@@ -0,0 +1,60 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+func TestGate(t *testing.T) {
+    jh := New(context.Background())
+    g := jh.Gate("compaction")
+    if g.IsOpen() || jh.TryFuncGroup("compaction", func (ctx context.Context) {}) {
+        t.Fatal("gated job taken while gate is closed")
+    }
+    if _, ok := jh.TryNamed("compaction"); ok {
+        t.Fatal("gated named job taken while gate is closed")
+    }
+    if !jh.TryFuncGroup("ingest", func (ctx context.Context) {}) {
+        t.Fatal("ungated job rejected")
+    }
+    g.Open()
+    if !jh.TryFuncGroup("compaction", func (ctx context.Context) {}) {
+        t.Fatal("gated job rejected while gate is open")
+    }
+    g.Close()
+    if jh.TryFuncGroup("compaction", func (ctx context.Context) {}) {
+        t.Fatal("gated job taken after gate was closed")
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func TestGateScheduled(t *testing.T) {
+    jh := New(context.Background())
+    g := jh.Gate("poll")
+    ran := make(chan struct{}, 1)
+    if !jh.TryAfter(time.Millisecond, func() { ran <- struct{}{} }, WithJobClass("poll")) {
+        t.Fatal("unable to try")
+    }
+    deadline := time.Now().Add(time.Second)
+    for jh.Stats().Skipped == 0 {
+        if time.Now().After(deadline) {
+            t.Fatal("gated run was not skipped")
+        }
+        time.Sleep(time.Millisecond)
+    }
+    if len(ran) != 0 {
+        t.Fatal("gated scheduled job ran")
+    }
+    g.Open()
+    if !jh.TryAfter(time.Millisecond, func() { ran <- struct{}{} }, WithJobClass("poll")) {
+        t.Fatal("unable to try")
+    }
+    select {
+    case <-ran:
+    case <-time.After(time.Second):
+        t.Fatal("scheduled job did not run while gate is open")
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
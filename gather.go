@@ -0,0 +1,90 @@
+package jobhandler
+
+import(
+    "context"
+    "errors"
+    "sync"
+)
+
+// errNegativeCount is returned by GatherN for a negative number of indices.
+var errNegativeCount = errors.New("jobhandler: negative job count")
+
+// Gather runs the functions fns concurrently as coupled jobs,
+// no more than limit at a time, and returns their results in argument order.
+// If limit is <= 0, all functions run at once.
+// Either all jobs are taken or none are taken, in which case
+// no function is run and ErrStopped is returned.
+// Each function is passed a context that is cancelled when the jobhandler
+// is stopped or another function returns an error.
+// The returned error is the first error returned by a function.
+// A panicking function is recovered and its panic returned
+// as a *PanicError.
+func Gather[T any](jh *JobHandler, limit int, fns ...func(context.Context) (T, error)) ([]T, error) {
+    ctx, cancel := context.WithCancel(jh.Context())
+    defer cancel()
+    results := make([]T, len(fns))
+    var (
+        wg       sync.WaitGroup
+        once     sync.Once
+        firstErr error
+    )
+    wg.Add(len(fns))
+    if !<-jh.TryNFuncAsync(len(fns), limit, func (i int) {
+        defer wg.Done()
+        v, err := gather(ctx, fns[i])
+        results[i] = v
+        if err != nil {
+            once.Do(func() {
+                firstErr = err
+                cancel()
+            })
+        }
+    }) {
+        return nil, ErrStopped
+    }
+    wg.Wait()
+    return results, firstErr
+}
+
+func gather[T any](ctx context.Context, fn func(context.Context) (T, error)) (v T, err error) {
+    defer recoverError(&err)
+    return fn(ctx)
+}
+
+// An Outcome is the result of a single index of GatherN.
+type Outcome[T any] struct {
+    Value T
+    // Err is the error returned for the index,
+    // or a *PanicError if its function panicked.
+    Err error
+}
+
+// GatherN runs fn for the indices 0 to n-1 concurrently as coupled jobs,
+// no more than limit at a time, like TryNFuncAsync, and returns the outcome
+// of each index. Unlike Gather, a failing or panicking index does not
+// cancel the others, so callers can act on partial success.
+// Either all jobs are taken or none are taken, in which case
+// fn is not run and ErrStopped is returned.
+// fn is passed a context that is cancelled when the jobhandler is stopped.
+// A negative n is rejected like by TryN and returns an error.
+func GatherN[T any](jh *JobHandler, n, limit int, fn func(ctx context.Context, i int) (T, error)) ([]Outcome[T], error) {
+    if n < 0 {
+        jh.reject("negative job count")
+        return nil, errNegativeCount
+    }
+    ctx := jh.Context()
+    outcomes := make([]Outcome[T], n)
+    var wg sync.WaitGroup
+    wg.Add(n)
+    if !<-jh.TryNFuncAsync(n, limit, func (i int) {
+        defer wg.Done()
+        o := &outcomes[i]
+        o.Value, o.Err = gather(ctx, func (ctx context.Context) (T, error) {
+            return fn(ctx, i)
+        })
+    }) {
+        return nil, ErrStopped
+    }
+    wg.Wait()
+    return outcomes, nil
+}
@@ -0,0 +1,107 @@
+package jobhandler
+import(
+    "context"
+    "errors"
+    "testing"
+)
+
+func TestGather(t *testing.T) {
+    t.Run("results", func (t *testing.T) {
+        jh := New(context.Background())
+        res, err := Gather(jh, 2,
+            func (ctx context.Context) (int, error) { return 1, nil },
+            func (ctx context.Context) (int, error) { return 2, nil },
+            func (ctx context.Context) (int, error) { return 3, nil },
+        )
+        if err != nil {
+            t.Fatal("unexpected error", err)
+        }
+        for i, v := range res {
+            if v != i + 1 {
+                t.Fatal("unexpected result", i, v)
+            }
+        }
+        jh.Stop()
+        jh.WaitAll()
+    })
+    t.Run("error", func (t *testing.T) {
+        jh := New(context.Background())
+        errTest := errors.New("test")
+        _, err := Gather(jh, 0,
+            func (ctx context.Context) (int, error) { return 0, errTest },
+            func (ctx context.Context) (int, error) {
+                <-ctx.Done()
+                return 0, ctx.Err()
+            },
+        )
+        if err != errTest {
+            t.Fatal("unexpected error", err)
+        }
+        jh.Stop()
+        jh.WaitAll()
+    })
+    t.Run("panic", func (t *testing.T) {
+        jh := New(context.Background())
+        errTest := errors.New("test")
+        _, err := Gather(jh, 0, func (ctx context.Context) (int, error) {
+            panic(errTest)
+        })
+        var perr *PanicError
+        if !errors.As(err, &perr) || !errors.Is(err, errTest) {
+            t.Fatal("unexpected error", err)
+        }
+        if len(perr.Stack) == 0 {
+            t.Fatal("missing stack")
+        }
+        jh.Stop()
+        jh.WaitAll()
+    })
+    t.Run("closed jobhandler", func (t *testing.T) {
+        jh := New(context.Background())
+        jh.Stop()
+        didRunFn := false
+        _, err := Gather(jh, 0, func (ctx context.Context) (int, error) {
+            didRunFn = true
+            return 0, nil
+        })
+        if err != ErrStopped {
+            t.Fatal("unexpected error", err)
+        }
+        jh.WaitAll()
+        if didRunFn {
+            t.Fatal("function did run")
+        }
+    })
+}
+
+func TestGatherN(t *testing.T) {
+    jh := New(context.Background())
+    errOdd := errors.New("odd")
+    outcomes, err := GatherN(jh, 4, 2, func (ctx context.Context, i int) (int, error) {
+        switch i {
+        case 1:
+            return 0, errOdd
+        case 2:
+            panic("boom")
+        }
+        return i * 10, nil
+    })
+    if err != nil {
+        t.Fatal("unexpected error", err)
+    }
+    var perr *PanicError
+    if outcomes[0].Value != 0 || outcomes[0].Err != nil || outcomes[3].Value != 30 || outcomes[3].Err != nil {
+        t.Fatal("lost results of succeeding indices", outcomes)
+    }
+    if outcomes[1].Err != errOdd || !errors.As(outcomes[2].Err, &perr) {
+        t.Fatal("unexpected errors", outcomes[1].Err, outcomes[2].Err)
+    }
+    if _, err := GatherN(jh, -1, 1, func (ctx context.Context, i int) (int, error) { return 0, nil }); err == nil {
+        t.Fatal("negative count should be rejected")
+    }
+    jh.Stop()
+    if _, err := GatherN(jh, 1, 1, func (ctx context.Context, i int) (int, error) { return 0, nil }); err != ErrStopped {
+        t.Fatal("unexpected error", err)
+    }
+    jh.WaitAll()
+}
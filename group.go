@@ -0,0 +1,97 @@
+package jobhandler
+
+import(
+    "context"
+)
+
+// group tracks the cancel functions of the running jobs of a group.
+// idle is closed when the last running job of the group is done.
+type group struct {
+    cancels map[uint64]context.CancelFunc
+    idle    chan struct{}
+}
+
+// TryFuncGroup is like TryFunc, but tags the job with group
+// and passes fn a context that is cancelled when the jobhandler is stopped
+// or the group is cancelled with CancelGroup.
+// Returns true if job is successfully taken
+// and false if the JobHandler is stopped or a gate of the group is closed,
+// see Gate.
+// Only jobs taken with TryFuncGroup belong to a group; jobs taken with
+// Try, TryN, TryFuncAsync or TryNFuncAsync cannot be grouped.
+func (jh *JobHandler) TryFuncGroup(group string, fn func(context.Context)) bool {
+    if !jh.tryN(1, group) {
+        return false
+    }
+    defer jh.doneN(1, group)
+    ctx, cancel := context.WithCancel(jh.Context())
+    defer cancel()
+    id := jh.addToGroup(group, cancel)
+    defer jh.removeFromGroup(group, id)
+    start := jh.clock().Now()
+    jh.runJob(group, func() { fn(ctx) })
+    d := jh.clock().Now().Sub(start)
+    jh.observeSLO(group, d)
+    jh.logSlow("group", group, d)
+    return true
+}
+
+// CancelGroup cancels the contexts of all running jobs tagged with group,
+// leaving other jobs untouched. The jobs are not flagged as done until
+// their functions exit. Returns the number of cancelled jobs.
+func (jh *JobHandler) CancelGroup(group string) int {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    g := jh.groups[group]
+    if g == nil {
+        return 0
+    }
+    for _, cancel := range g.cancels {
+        cancel()
+    }
+    return len(g.cancels)
+}
+
+// WaitFor blocks until all running jobs tagged with group are done,
+// including jobs that join the group while waiting.
+// Returns immediately if no job of the group is running.
+// The jobhandler keeps running, so WaitFor may be used to sequence
+// phases of work within a live jobhandler.
+func (jh *JobHandler) WaitFor(group string) {
+    jh.mu.Lock()
+    g := jh.groups[group]
+    jh.mu.Unlock()
+    if g != nil {
+        <-g.idle
+    }
+}
+
+func (jh *JobHandler) addToGroup(name string, cancel context.CancelFunc) uint64 {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    if jh.groups == nil {
+        jh.groups = make(map[string]*group)
+    }
+    g := jh.groups[name]
+    if g == nil {
+        g = &group{
+            cancels: make(map[uint64]context.CancelFunc),
+            idle:    make(chan struct{}),
+        }
+        jh.groups[name] = g
+    }
+    jh.lastID++
+    g.cancels[jh.lastID] = cancel
+    return jh.lastID
+}
+
+func (jh *JobHandler) removeFromGroup(name string, id uint64) {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    g := jh.groups[name]
+    delete(g.cancels, id)
+    if len(g.cancels) == 0 {
+        delete(jh.groups, name)
+        close(g.idle)
+    }
+}
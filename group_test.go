@@ -0,0 +1,67 @@
+package jobhandler
+import(
+    "context"
+    "sync/atomic"
+    "testing"
+)
+
+func TestCancelGroup(t *testing.T) {
+    jh := New(context.Background())
+    started := make(chan struct{})
+    results := make(chan string, 2)
+    for _, tenant := range []string{"a", "b"} {
+        go jh.TryFuncGroup(tenant, func (ctx context.Context) {
+            started <- struct{}{}
+            <-ctx.Done()
+            results <- tenant
+        })
+    }
+    <-started
+    <-started
+    if n := jh.CancelGroup("a"); n != 1 {
+        t.Fatal("unexpected cancel count", n)
+    }
+    if tenant := <-results; tenant != "a" {
+        t.Fatal("unexpected tenant cancelled", tenant)
+    }
+    jh.WaitFor("a")
+    if n := jh.CancelGroup("a"); n != 0 {
+        t.Fatal("unexpected cancel count", n)
+    }
+    jh.Stop()
+    if tenant := <-results; tenant != "b" {
+        t.Fatal("unexpected tenant cancelled", tenant)
+    }
+    jh.WaitAll()
+    if jh.TryFuncGroup("a", func (ctx context.Context) {}) {
+        t.Fatal("should not accept jobs")
+    }
+}
+
+func TestWaitFor(t *testing.T) {
+    jh := New(context.Background())
+    jh.WaitFor("index-build")
+    started := make(chan struct{})
+    release := make(chan struct{})
+    var nDone atomic.Int32
+    for i := 0; i < 3; i++ {
+        go jh.TryFuncGroup("index-build", func (ctx context.Context) {
+            started <- struct{}{}
+            <-release
+            nDone.Add(1)
+        })
+    }
+    for i := 0; i < 3; i++ {
+        <-started
+    }
+    close(release)
+    jh.WaitFor("index-build")
+    if n := nDone.Load(); n != 3 {
+        t.Fatal("unexpected done count", n)
+    }
+    if jh.Stopped() {
+        t.Fatal("should not be stopped")
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
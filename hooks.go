@@ -0,0 +1,137 @@
+package jobhandler
+
+import(
+    "sync"
+)
+
+// A Summary describes how a jobhandler shut down.
+// It is passed to the hooks registered with AfterDrain.
+type Summary struct {
+    // Name is the name of the jobhandler.
+    Name string
+    // Cause is the stop cause, see StopCause.
+    Cause error
+    // Abandoned is the number of jobs abandoned by ForceStop.
+    Abandoned int64
+    // AbandonedGroups holds the number of abandoned jobs per group,
+    // see TryFuncGroup.
+    AbandonedGroups map[string]int
+    // Stats holds the counters of the jobhandler.
+    Stats Stats
+}
+
+// WithHookConcurrency makes the jobhandler run up to n of its AfterDrain
+// hooks at once, e.g. when they are independent network calls,
+// instead of one after the other in registration order.
+func WithHookConcurrency(n int) Option {
+    return func(jh *JobHandler) {
+        jh.hookLimit = n
+    }
+}
+
+// AfterDrain registers fn to be called once the jobhandler is stopped and
+// all jobs are done or abandoned. The hooks run in registration order,
+// see WithHookConcurrency, and WaitStopped and WaitAll do not return
+// before they are complete.
+// If the hooks of the jobhandler have already run, fn is called immediately.
+func (jh *JobHandler) AfterDrain(fn func(Summary)) {
+    jh.mu.Lock()
+    if !jh.hooksRun && jh.stopChan != nil {
+        jh.hooks = append(jh.hooks, fn)
+        jh.mu.Unlock()
+        return
+    }
+    jh.mu.Unlock()
+    fn(jh.summary())
+}
+
+// OnStopFunc registers fn to be called once when the jobhandler is stopped,
+// on the goroutine that stops it, before Stop returns. The functions run in
+// registration order, so they should be quick, e.g. closing a listener.
+// If the jobhandler is already stopped, fn is called immediately.
+func (jh *JobHandler) OnStopFunc(fn func()) {
+    jh.mu.Lock()
+    if !jh.stopFuncsRun && jh.stopChan != nil {
+        jh.stopFuncs = append(jh.stopFuncs, fn)
+        jh.mu.Unlock()
+        return
+    }
+    jh.mu.Unlock()
+    fn()
+}
+
+// runStopFuncs runs the functions registered with OnStopFunc.
+func (jh *JobHandler) runStopFuncs() {
+    jh.mu.Lock()
+    jh.stopFuncsRun = true
+    fns := jh.stopFuncs
+    jh.stopFuncs = nil
+    jh.mu.Unlock()
+    for _, fn := range fns {
+        fn()
+    }
+}
+
+// summary returns the shutdown summary of the jobhandler.
+func (jh *JobHandler) summary() Summary {
+    jh.mu.Lock()
+    s := Summary{
+        Name:      jh.name,
+        Abandoned: jh.abandoned,
+    }
+    if len(jh.abandonedGroups) > 0 {
+        s.AbandonedGroups = make(map[string]int, len(jh.abandonedGroups))
+        for g, n := range jh.abandonedGroups {
+            s.AbandonedGroups[g] = n
+        }
+    }
+    jh.mu.Unlock()
+    s.Cause = jh.StopCause()
+    s.Stats = jh.Stats()
+    return s
+}
+
+// runHooks runs the hooks registered with AfterDrain, closes the values
+// set with SetValue and then closes stoppedChan.
+func (jh *JobHandler) runHooks() {
+    jh.mu.Lock()
+    jh.hooksRun = true
+    hooks := jh.hooks
+    jh.hooks = nil
+    jh.mu.Unlock()
+    if len(hooks) == 0 && jh.marker == nil {
+        jh.closeValues()
+        close(jh.stoppedChan)
+        return
+    }
+    go func() {
+        s := jh.summary()
+        if jh.hookLimit > 1 {
+            runConcurrently(hooks, jh.hookLimit, s)
+        } else {
+            for _, hook := range hooks {
+                hook(s)
+            }
+        }
+        jh.closeValues()
+        jh.storeMarker(s)
+        close(jh.stoppedChan)
+    }()
+}
+
+// runConcurrently runs the hooks with s, no more than limit at a time,
+// and waits for them to complete.
+func runConcurrently(hooks []func(Summary), limit int, s Summary) {
+    var wg sync.WaitGroup
+    sem := make(chan struct{}, limit)
+    for _, hook := range hooks {
+        sem <- struct{}{}
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            defer func() { <-sem }()
+            hook(s)
+        }()
+    }
+    wg.Wait()
+}
@@ -0,0 +1,81 @@
+package jobhandler
+import(
+    "context"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestAfterDrain(t *testing.T) {
+    t.Run("graceful", func (t *testing.T) {
+        jh := New(context.Background(), WithName("server"))
+        var order []int
+        jh.AfterDrain(func (s Summary) { order = append(order, 1) })
+        jh.AfterDrain(func (s Summary) {
+            order = append(order, 2)
+            if s.Name != "server" || s.Cause != ErrStopped || s.Abandoned != 0 {
+                t.Error("unexpected summary", s)
+            }
+        })
+        jh.Stop()
+        jh.WaitAll()
+        if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+            t.Fatal("unexpected hook order", order)
+        }
+        ran := false
+        jh.AfterDrain(func (s Summary) { ran = true })
+        if !ran {
+            t.Fatal("hook registered after drain did not run")
+        }
+    })
+    t.Run("forced", func (t *testing.T) {
+        jh := New(context.Background())
+        started := make(chan struct{})
+        go jh.TryFuncGroup("tenant42", func (ctx context.Context) {
+            close(started)
+            <-jh.ForceContext().Done()
+        })
+        <-started
+        var s Summary
+        jh.AfterDrain(func (summary Summary) { s = summary })
+        jh.StopWithTimeout(time.Millisecond)
+        jh.WaitAll()
+        if s.Abandoned != 1 || s.AbandonedGroups["tenant42"] != 1 || s.Cause != ErrStopped {
+            t.Fatal("unexpected summary", s)
+        }
+    })
+}
+
+func TestWithHookConcurrency(t *testing.T) {
+    jh := New(context.Background(), WithHookConcurrency(2))
+    var wg sync.WaitGroup
+    wg.Add(2)
+    for i := 0; i < 2; i++ {
+        jh.AfterDrain(func (s Summary) {
+            // Each hook waits for the other to start
+            wg.Done()
+            wg.Wait()
+        })
+    }
+    jh.Stop()
+    if !jh.WaitTimeout(time.Second) {
+        t.Fatal("hooks did not run concurrently")
+    }
+}
+
+func TestOnStopFunc(t *testing.T) {
+    jh := New(context.Background())
+    var order []int
+    jh.OnStopFunc(func() { order = append(order, 1) })
+    jh.OnStopFunc(func() { order = append(order, 2) })
+    jh.Stop()
+    if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+        t.Fatal("unexpected order", order)
+    }
+    jh.Stop()
+    jh.OnStopFunc(func() { order = append(order, 3) })
+    if len(order) != 3 {
+        t.Fatal("function registered after stop did not run", order)
+    }
+    jh.WaitAll()
+}
@@ -0,0 +1,153 @@
+package jobhandler
+
+import(
+    "bytes"
+    "context"
+    "math"
+    "net"
+    "net/http"
+    "strconv"
+    "text/template"
+    "time"
+)
+
+// An HTTPOption configures the rejection response of Middleware.
+type HTTPOption func(*httpConfig)
+
+type httpConfig struct {
+    status      int
+    retryAfter  time.Duration
+    contentType string
+    body        *template.Template
+}
+
+// RejectInfo is the data passed to the body template of WithRejectBody.
+type RejectInfo struct {
+    // StatusCode is the status code of the response.
+    StatusCode int
+    // Reason is the stop cause of the jobhandler, see StopCause.
+    Reason string
+    // RetryAfter is the Retry-After header value in seconds, or 0 if not set.
+    RetryAfter int
+    // Handler is the name of the jobhandler.
+    Handler string
+}
+
+// WithRejectStatus sets the status code of rejected requests.
+// The default is 503 Service Unavailable.
+func WithRejectStatus(code int) HTTPOption {
+    return func(cfg *httpConfig) {
+        cfg.status = code
+    }
+}
+
+// WithRetryAfter sets the Retry-After header of rejected requests to d.
+// If the jobhandler has a grace deadline, see StopWithTimeout,
+// the time until the deadline is used instead.
+func WithRetryAfter(d time.Duration) HTTPOption {
+    return func(cfg *httpConfig) {
+        cfg.retryAfter = d
+    }
+}
+
+// WithRejectBody sets the body of rejected requests to the text/template tmpl,
+// executed with a RejectInfo, e.g. for a machine-readable JSON body:
+//
+//     WithRejectBody("application/json", `{"error":"draining","retry_after":{{.RetryAfter}}}`)
+//
+// WithRejectBody panics if tmpl cannot be parsed.
+func WithRejectBody(contentType, tmpl string) HTTPOption {
+    t := template.Must(template.New("reject").Parse(tmpl))
+    return func(cfg *httpConfig) {
+        cfg.contentType = contentType
+        cfg.body = t
+    }
+}
+
+// Middleware returns an http.Handler that serves each request with next
+// as a job of the jobhandler, and rejects requests once the jobhandler is
+// stopped. By default requests are rejected with 503 Service Unavailable
+// and an empty body, see the HTTPOption functions to change that.
+func (jh *JobHandler) Middleware(next http.Handler, opts ...HTTPOption) http.Handler {
+    cfg := httpConfig{status: http.StatusServiceUnavailable}
+    for _, opt := range opts {
+        opt(&cfg)
+    }
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if !jh.Try() {
+            jh.writeRejection(w, &cfg)
+            return
+        }
+        defer jh.Done()
+        next.ServeHTTP(w, r)
+    })
+}
+
+func (jh *JobHandler) writeRejection(w http.ResponseWriter, cfg *httpConfig) {
+    info := RejectInfo{
+        StatusCode: cfg.status,
+        Handler:    jh.name,
+    }
+    if err := jh.StopCause(); err != nil {
+        info.Reason = err.Error()
+    }
+    retryAfter := cfg.retryAfter
+    if deadline, ok := jh.GraceDeadline(); ok {
+        retryAfter = deadline.Sub(jh.clock().Now())
+    }
+    if retryAfter > 0 {
+        info.RetryAfter = int(math.Ceil(retryAfter.Seconds()))
+        w.Header().Set("Retry-After", strconv.Itoa(info.RetryAfter))
+    }
+    if cfg.body == nil {
+        w.WriteHeader(cfg.status)
+        return
+    }
+    var buf bytes.Buffer
+    if err := cfg.body.Execute(&buf, info); err != nil {
+        w.WriteHeader(http.StatusInternalServerError)
+        return
+    }
+    w.Header().Set("Content-Type", cfg.contentType)
+    w.WriteHeader(cfg.status)
+    w.Write(buf.Bytes())
+}
+
+// Serve serves srv on ln as a job of the jobhandler until the jobhandler
+// is stopped, at which point srv is shut down gracefully, see
+// http.Server.Shutdown. If the shutdown takes longer than timeout,
+// the remaining connections are closed, see http.Server.Close.
+// A timeout <= 0 waits for the shutdown indefinitely.
+// Serve blocks until srv is shut down, and WaitAll does not return before.
+// Wrap srv.Handler with Middleware to also track the individual requests.
+// Returns ErrStopped if the jobhandler is stopped, and otherwise the error
+// of serving or shutting down srv, if any.
+func (jh *JobHandler) Serve(srv *http.Server, ln net.Listener, timeout time.Duration) error {
+    if !jh.Try() {
+        return ErrStopped
+    }
+    defer jh.Done()
+    serveErr := make(chan error, 1)
+    go func() {
+        serveErr <- srv.Serve(ln)
+    }()
+    select {
+    case err := <-serveErr:
+        return err
+    case <-jh.stopCh():
+    }
+    ctx := context.Background()
+    if timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, timeout)
+        defer cancel()
+    }
+    err := srv.Shutdown(ctx)
+    if err != nil {
+        srv.Close()
+    }
+    if serr := <-serveErr; serr != http.ErrServerClosed {
+        return serr
+    }
+    return err
+}
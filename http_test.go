@@ -0,0 +1,106 @@
+package jobhandler
+import(
+    "context"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestMiddleware(t *testing.T) {
+    jh := New(context.Background())
+    h := jh.Middleware(http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("ok"))
+    }),
+        WithRejectStatus(http.StatusTooManyRequests),
+        WithRetryAfter(5 * time.Second),
+        WithRejectBody("application/json", `{"error":"{{.Reason}}","retry_after":{{.RetryAfter}}}`),
+    )
+    rec := httptest.NewRecorder()
+    h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+    if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+        t.Fatal("unexpected response", rec.Code, rec.Body.String())
+    }
+    jh.Stop()
+    rec = httptest.NewRecorder()
+    h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+    if rec.Code != http.StatusTooManyRequests {
+        t.Fatal("unexpected status", rec.Code)
+    }
+    if v := rec.Header().Get("Retry-After"); v != "5" {
+        t.Fatal("unexpected Retry-After", v)
+    }
+    if rec.Header().Get("Content-Type") != "application/json" || rec.Body.String() != `{"error":"jobhandler: stopped","retry_after":5}` {
+        t.Fatal("unexpected body", rec.Body.String())
+    }
+    jh.WaitAll()
+}
+
+func TestMiddlewareGraceDeadline(t *testing.T) {
+    jh := New(context.Background())
+    if !jh.Try() {
+        t.Fatal("unable to try")
+    }
+    go jh.StopWithTimeout(30 * time.Second)
+    for {
+        if _, ok := jh.GraceDeadline(); ok && jh.Stopped() {
+            break
+        }
+        time.Sleep(time.Millisecond)
+    }
+    rec := httptest.NewRecorder()
+    jh.Middleware(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+    if rec.Code != http.StatusServiceUnavailable {
+        t.Fatal("unexpected status", rec.Code)
+    }
+    if v := rec.Header().Get("Retry-After"); v != "30" {
+        t.Fatal("unexpected Retry-After", v)
+    }
+    jh.Done()
+    jh.WaitAll()
+}
+
+func TestServe(t *testing.T) {
+    jh := New(context.Background())
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    started := make(chan struct{})
+    release := make(chan struct{})
+    srv := &http.Server{Handler: http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
+        close(started)
+        <-release
+        w.Write([]byte("ok"))
+    })}
+    serveErr := make(chan error, 1)
+    go func () {
+        serveErr <- jh.Serve(srv, ln, time.Second)
+    }()
+    body := make(chan string, 1)
+    go func () {
+        resp, err := http.Get("http://" + ln.Addr().String())
+        if err != nil {
+            body <- err.Error()
+            return
+        }
+        defer resp.Body.Close()
+        b, _ := io.ReadAll(resp.Body)
+        body <- string(b)
+    }()
+    <-started
+    jh.Stop()
+    close(release)
+    if b := <-body; b != "ok" {
+        t.Fatal("in-flight request was not served", b)
+    }
+    if err := <-serveErr; err != nil {
+        t.Fatal("unexpected error", err)
+    }
+    jh.WaitAll()
+    if err := jh.Serve(srv, ln, time.Second); err != ErrStopped {
+        t.Fatal("unexpected error", err)
+    }
+}
@@ -0,0 +1,212 @@
+package jobhandler
+
+import(
+    "cmp"
+    "context"
+    "fmt"
+    "runtime"
+    "slices"
+    "sync"
+    "time"
+)
+
+// A Job is a single job taken with TryJob.
+// Unlike the shared Done method of the jobhandler, the job's own Done
+// attributes a double Done to the job and the caller that flagged it first.
+type Job struct {
+    jh       *JobHandler
+    id       uint64
+    name     string
+    priority Priority
+    parent   context.Context
+    ctx      context.Context
+    cancel   context.CancelFunc
+    start    time.Time
+    // expires is set for jobs taken with TryCtx,
+    // which are counted as expired if the deadline of parent passed
+    expires  bool
+    mu       sync.Mutex
+    done     bool
+    doneAt   string
+    // stuck is set once the job is reported by WithSlowJobThreshold,
+    // guarded by the mutex of the jobhandler
+    stuck    bool
+}
+
+// A JobInfo describes an in-flight job, see Jobs.
+type JobInfo struct {
+    Name    string
+    Start   time.Time
+    Elapsed time.Duration
+}
+
+// JobTimes aggregates the wall time of the jobs of a name, see WithJobTimes.
+type JobTimes struct {
+    // Count is the number of jobs that are done.
+    Count uint64
+    // Wall is the total wall time of the jobs that are done.
+    Wall time.Duration
+    // Max is the longest wall time of a job.
+    Max time.Duration
+}
+
+// WithJobTimes makes the jobhandler aggregate the wall time of the jobs
+// taken with TryJob and TryNamed per job name, see Stats.Jobs.
+func WithJobTimes() Option {
+    return func(jh *JobHandler) {
+        jh.jobTimes = make(map[string]*JobTimes)
+    }
+}
+
+// TryJob attempts to take on a single job like Try.
+// Returns the job and true if it is successfully taken
+// and false if the JobHandler is stopped.
+// When the job is done call its Done method, not the one of the jobhandler.
+// Of the job options, only WithJobPriority and WithJobContext apply.
+func (jh *JobHandler) TryJob(opts ...JobOption) (*Job, bool) {
+    return jh.TryNamed("", opts...)
+}
+
+// TryNamed is like TryJob, but names the job, e.g. "reindex",
+// so it can be told apart in Jobs and Dump.
+// Named jobs are rejected while a gate of the name is closed, see Gate.
+func (jh *JobHandler) TryNamed(name string, opts ...JobOption) (*Job, bool) {
+    cfg := newJobConfig(opts)
+    if !cfg.hasPrio {
+        cfg.priority = PriorityNormal
+    }
+    if !jh.tryPriority(cfg.priority, name) {
+        return nil, false
+    }
+    j := &Job{jh: jh, name: name, priority: cfg.priority, start: jh.clock().Now()}
+    if cfg.ctx != nil {
+        j.parent = cfg.ctx
+        j.ctx, j.cancel = jh.withStop(cfg.ctx)
+    }
+    jh.mu.Lock()
+    if jh.jobs == nil {
+        jh.jobs = make(map[*Job]struct{})
+    }
+    jh.lastID++
+    j.id = jh.lastID
+    jh.jobs[j] = struct{}{}
+    jh.mu.Unlock()
+    return j, true
+}
+
+// Jobs lists the in-flight jobs taken with TryJob and TryNamed,
+// oldest first. Jobs taken otherwise are not listed.
+func (jh *JobHandler) Jobs() []JobInfo {
+    now := jh.clock().Now()
+    jh.mu.Lock()
+    jobs := make([]*Job, 0, len(jh.jobs))
+    for j := range jh.jobs {
+        jobs = append(jobs, j)
+    }
+    jh.mu.Unlock()
+    slices.SortFunc(jobs, func(a, b *Job) int {
+        return cmp.Compare(a.id, b.id)
+    })
+    infos := make([]JobInfo, len(jobs))
+    for i, j := range jobs {
+        infos[i] = JobInfo{Name: j.name, Start: j.start, Elapsed: now.Sub(j.start)}
+    }
+    return infos
+}
+
+// Spawn takes a child job of j from the jobhandler of j, e.g. to fan out
+// work. Unless overridden by opts, the child inherits the name, priority
+// and context of j, and with the context its deadline, values and trace
+// task. If name is empty, the name of j is used.
+// The child is not done when j is done; call its own Done method.
+func (j *Job) Spawn(name string, opts ...JobOption) (*Job, bool) {
+    if name == "" {
+        name = j.name
+    }
+    inherited := []JobOption{WithJobPriority(j.priority)}
+    if j.parent != nil {
+        inherited = append(inherited, WithJobContext(j.parent))
+    }
+    return j.jh.TryNamed(name, append(inherited, opts...)...)
+}
+
+// Name returns the name of the job, see TryNamed.
+func (j *Job) Name() string {
+    return j.name
+}
+
+// Priority returns the priority of the job, see WithJobPriority.
+func (j *Job) Priority() Priority {
+    return j.priority
+}
+
+// Context returns a context that is cancelled when the job is done,
+// the jobhandler is stopped or the context bound to the job with
+// WithJobContext is done. Without a bound context, it is the context
+// of the jobhandler, see JobHandler.Context.
+func (j *Job) Context() context.Context {
+    if j.ctx == nil {
+        return j.jh.Context()
+    }
+    return j.ctx
+}
+
+// Done flags the job as done.
+// Done panics if the job is already done, reporting where it was done first.
+func (j *Job) Done() {
+    at := "unknown location"
+    if _, file, line, ok := runtime.Caller(1); ok {
+        at = fmt.Sprintf("%s:%d", file, line)
+    }
+    j.mu.Lock()
+    if j.done {
+        first := j.doneAt
+        j.mu.Unlock()
+        j.jh.panic("job done twice, at " + at + " and first at " + first)
+    }
+    j.done = true
+    j.doneAt = at
+    j.mu.Unlock()
+    if j.cancel != nil {
+        j.cancel()
+    }
+    var elapsed time.Duration
+    if j.jh.jobTimes != nil || j.jh.slowJob > 0 {
+        elapsed = j.jh.clock().Now().Sub(j.start)
+        j.jh.logSlow("job", j.name, elapsed)
+    }
+    j.jh.mu.Lock()
+    delete(j.jh.jobs, j)
+    if j.jh.jobTimes != nil {
+        t := j.jh.jobTimes[j.name]
+        if t == nil {
+            t = &JobTimes{}
+            j.jh.jobTimes[j.name] = t
+        }
+        t.add(elapsed)
+    }
+    j.jh.mu.Unlock()
+    if j.expires {
+        j.jh.checkExpired(j.parent)
+    }
+    j.jh.doneN(1, j.name)
+}
+
+func (t *JobTimes) add(d time.Duration) {
+    t.Count++
+    t.Wall += d
+    t.Max = max(t.Max, d)
+}
+
+func (jh *JobHandler) jobTimesStats() map[string]JobTimes {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    if len(jh.jobTimes) == 0 {
+        return nil
+    }
+    m := make(map[string]JobTimes, len(jh.jobTimes))
+    for name, t := range jh.jobTimes {
+        m[name] = *t
+    }
+    return m
+}
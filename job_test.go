@@ -0,0 +1,108 @@
+package jobhandler
+import(
+    "context"
+    "strings"
+    "testing"
+)
+
+func TestTryJob(t *testing.T) {
+    jh := New(context.Background(), WithName("server"))
+    job, ok := jh.TryJob()
+    if !ok {
+        t.Fatal("failed to take job")
+    }
+    job.Done()
+    func() {
+        defer func() {
+            msg, _ := recover().(string)
+            if !strings.HasPrefix(msg, "jobhandler server: job done twice") || !strings.Contains(msg, "job_test.go") {
+                t.Fatal("unexpected panic", msg)
+            }
+        }()
+        job.Done()
+    }()
+    jh.Stop()
+    if _, ok := jh.TryJob(); ok {
+        t.Fatal("took a job after stop")
+    }
+    jh.WaitAll()
+}
+
+func TestTryNamed(t *testing.T) {
+    jh := New(context.Background())
+    reindex, _ := jh.TryNamed("reindex")
+    compact, _ := jh.TryNamed("compact")
+    jobs := jh.Jobs()
+    if len(jobs) != 2 || jobs[0].Name != "reindex" || jobs[1].Name != "compact" {
+        t.Fatal("unexpected jobs", jobs)
+    }
+    if jobs[0].Elapsed < jobs[1].Elapsed {
+        t.Fatal("oldest job listed with a shorter elapsed time", jobs)
+    }
+    var sb strings.Builder
+    jh.Dump(&sb)
+    if !strings.Contains(sb.String(), "job compact: running for") {
+        t.Fatalf("dump does not list the job:\n%s", sb.String())
+    }
+    reindex.Done()
+    if jobs := jh.Jobs(); len(jobs) != 1 || jobs[0].Name != "compact" {
+        t.Fatal("unexpected jobs", jobs)
+    }
+    compact.Done()
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func TestWithJobTimes(t *testing.T) {
+    jh := New(context.Background(), WithJobTimes())
+    for i := 0; i < 3; i++ {
+        job, _ := jh.TryNamed("reindex")
+        job.Done()
+    }
+    times := jh.Stats().Jobs
+    if r := times["reindex"]; len(times) != 1 || r.Count != 3 || r.Max > r.Wall {
+        t.Fatal("unexpected job times", times)
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func TestSpawn(t *testing.T) {
+    jh := New(context.Background(), WithCriticalJobs(1))
+    type key struct{}
+    ctx := context.WithValue(context.Background(), key{}, "trace")
+    parent, ok := jh.TryNamed("fanout", WithJobPriority(PriorityHigh), WithJobContext(ctx))
+    if !ok {
+        t.Fatal("failed to take job")
+    }
+    child, ok := parent.Spawn("")
+    if !ok {
+        t.Fatal("failed to spawn job")
+    }
+    if child.Name() != "fanout" || child.Priority() != PriorityHigh {
+        t.Fatal("child did not inherit name and priority", child.Name(), child.Priority())
+    }
+    if child.Context().Value(key{}) != "trace" {
+        t.Fatal("child did not inherit context")
+    }
+    low, ok := parent.Spawn("prefetch", WithJobPriority(PriorityLow))
+    if !ok || low.Priority() != PriorityLow || low.Name() != "prefetch" {
+        t.Fatal("spawn options did not override inherited metadata")
+    }
+    parent.Done()
+    if parent.Context().Err() == nil {
+        t.Fatal("parent context not cancelled when done")
+    }
+    if child.Context().Err() != nil {
+        t.Fatal("child context cancelled when parent done")
+    }
+    low.Done()
+    jh.Stop()
+    critical, ok := child.Spawn("", WithJobPriority(PriorityCritical))
+    if !ok {
+        t.Fatal("failed to spawn critical job while draining")
+    }
+    child.Done()
+    critical.Done()
+    jh.WaitAll()
+}
@@ -2,40 +2,401 @@ package jobhandler
 
 import(
     "context"
+    "errors"
+    "log/slog"
+    "math/rand"
+    "os"
+    "runtime"
+    "runtime/trace"
+    "slices"
     "sync"
     "sync/atomic"
     "time"
 )
 
+// ErrStopped is returned by functions that fail because
+// the jobhandler is stopped.
+var ErrStopped = errors.New("jobhandler: stopped")
+
+// ErrMaxTotalJobs is the stop cause of a jobhandler that was stopped
+// because it accepted the number of jobs set by WithMaxTotalJobs.
+var ErrMaxTotalJobs = errors.New("jobhandler: max total jobs accepted")
+
+// canceledCtx is the context of a zero jobhandler.
+var canceledCtx = func() context.Context {
+    ctx, cancel := context.WithCancelCause(context.Background())
+    cancel(ErrStopped)
+    return ctx
+}()
+
 // A jobhandler accepts new jobs until the jobhandler is stopped, at which point
 // any new job is rejected.
 // A zero jobhandler is valid, but is considered stopped and will not accept any jobs.
 type JobHandler struct {
-    n        int64
-    stopChan chan struct{}
-    running  atomic.Bool
-    wg       sync.WaitGroup
+    // n is the number of jobs that are not done,
+    // with runningBit and drainedBit, see settle
+    n               int64
+    name            string
+    stopChan        chan struct{}
+    ctx             context.Context
+    cancel          context.CancelCauseFunc
+    forced          atomic.Bool
+    traceLog        bool
+    forceCtx        context.Context
+    forceCancel     context.CancelCauseFunc
+    drainedChan     chan struct{}
+    stoppedChan     chan struct{}
+    mu              sync.Mutex
+    state           State
+    watchers        []chan State
+    groups          map[string]*group
+    hooks           []func(Summary)
+    hooksRun        bool
+    values          map[any]any
+    closers         []any
+    abandoned       int64
+    abandonedGroups map[string]int
+    slos            map[string]*SLOStats
+    lastID          uint64
+    graceDeadline   time.Time
+    rejects         *rejectLog
+    clk             Clock
+    signals         []os.Signal
+    expired         atomic.Uint64
+    skipped         atomic.Uint64
+    lateDone        atomic.Uint64
+    accepted        shardedCounter
+    rejected        atomic.Uint64
+    critical        atomic.Int64
+    criticalMax     int64
+    parent          context.Context
+    hookLimit       int
+    sleepers        map[string]*sleepers
+    phases          map[int]*JobHandler
+    phasesStopping  bool
+    stopFuncs       []func()
+    stopFuncsRun    bool
+    stages          []AdmissionStage
+    pipeline        []AdmissionStage
+    pipelineFn      func([]AdmissionStage) []AdmissionStage
+    announceChan    chan struct{}
+    announced       atomic.Bool
+    maxConcurrent   atomic.Int64
+    capacity        notifier
+    logger          *slog.Logger
+    slowJob         time.Duration
+    vetoes          []func(string) time.Duration
+    vetoWindow      time.Duration
+    stopRequested   atomic.Bool
+    drainTask       *trace.Task
+    gates           []*Gate
+    marker          MarkerStore
+    cleanStart      bool
+    markerErr       error
+    maxTotal        int64
+    total           atomic.Int64
+    budget          *Budget
+    children        []*JobHandler
+    panicHandler    func(any)
+    jobs            map[*Job]struct{}
+    waiters         map[*waiter]struct{}
+    keyLimit        int
+    keys            map[string]int
+    preStopDelay    time.Duration
+    preStopping     atomic.Bool
+    isDrained       atomic.Bool
+    rate            *bucket
+    unbounded       atomic.Bool
+    thresholds      map[Priority]float64
+    queue           *jobQueue
+    dropped         atomic.Uint64
+    probes          []*probe
+    probeInterval   time.Duration
+    paused          atomic.Bool
+    stuckAfter      time.Duration
+    onStuck         func(JobInfo)
+    jobTimes        map[string]*JobTimes
+}
+
+// Stats holds counters describing the jobs taken by a jobhandler.
+type Stats struct {
+    // Expired is the number of jobs taken with TryCtx that were
+    // auto-cancelled because the deadline of their context passed.
+    Expired uint64
+    // Skipped is the number of batch and delayed jobs that were never
+    // started because the jobhandler was stopped, see SkipOnStop and TryAfter.
+    Skipped uint64
+    // LateDone is the number of Done calls that arrived after ForceStop
+    // had abandoned the jobs, and were ignored.
+    LateDone uint64
+    // Dropped is the number of queued jobs that were dropped
+    // because the jobhandler was stopped, see WithQueue.
+    Dropped uint64
+    // SLOs holds the SLO stats of each group with an SLO, see SetSLO.
+    SLOs map[string]SLOStats
+    // Jobs holds the wall times of the jobs of each name, see WithJobTimes.
+    Jobs map[string]JobTimes
+}
+
+// A JobOption configures how the Func helpers run their jobs.
+type JobOption func(*jobConfig)
+
+type jobConfig struct {
+    skipOnStop bool
+    onSkip     func(int)
+    lockThread bool
+    seeded     bool
+    seed       int64
+    pace       time.Duration
+    priority   Priority
+    hasPrio    bool
+    ctx        context.Context
+    jitter     time.Duration
+    fixedRate  bool
+    wakeName   string
+    class      string
+}
+
+func newJobConfig(opts []JobOption) jobConfig {
+    var cfg jobConfig
+    for _, opt := range opts {
+        opt(&cfg)
+    }
+    return cfg
+}
+
+// SkipOnStop makes TryNFuncAsync skip the job indices that are not yet
+// started when the jobhandler is stopped, instead of still launching them.
+// Skipped jobs are flagged as done and counted in Stats. If report is not nil,
+// it is called with the number of skipped indices once they are known.
+func SkipOnStop(report func(skipped int)) JobOption {
+    return func(cfg *jobConfig) {
+        cfg.skipOnStop = true
+        cfg.onSkip = report
+    }
+}
+
+// WithLockedThread makes the Func helpers run the job functions with the
+// goroutine locked to its OS thread, see runtime.LockOSThread.
+// Use it for jobs calling thread-sensitive C libraries.
+// TryNFuncAsync locks each worker goroutine for all of its calls.
+func WithLockedThread() JobOption {
+    return func(cfg *jobConfig) {
+        cfg.lockThread = true
+    }
+}
+
+// WithOrderSeed makes TryNFuncAsync start the job indices in a pseudo-random
+// order determined by seed, instead of counting up from 0. If seed is 0,
+// a random seed is chosen for each call. The seed in effect is logged at
+// info level to the logger set by WithLogger.
+// Use it to reproduce an ordering, e.g. one that made a test fail in CI,
+// by running with the logged seed. Only the start order is reproducible:
+// with a limit above 1 the calls still interleave as scheduled by the
+// runtime, so use a limit of 1 to reproduce a run exactly.
+func WithOrderSeed(seed int64) JobOption {
+    return func(cfg *jobConfig) {
+        cfg.seeded = true
+        cfg.seed = seed
+    }
+}
+
+// WithPacing makes TryNFuncAsync spread the start of its jobs evenly
+// over d, e.g. 10k notifications over 5 minutes, instead of starting them
+// as fast as its limit allows. Job i starts no earlier than i*d/delta
+// after the first. When the jobhandler is stopped, the jobs that are not
+// yet started are skipped like with SkipOnStop, instead of starting at once,
+// and reported to the SkipOnStop callback if one is given.
+func WithPacing(d time.Duration) JobOption {
+    return func(cfg *jobConfig) {
+        cfg.pace = d
+    }
+}
+
+// WithJobPriority makes TryJob, TryNamed and Spawn take the job
+// with priority p, see TryPriority. The default is PriorityNormal.
+func WithJobPriority(p Priority) JobOption {
+    return func(cfg *jobConfig) {
+        cfg.priority = p
+        cfg.hasPrio = true
+    }
+}
+
+// WithJobContext makes TryJob, TryNamed and Spawn bind the job to ctx,
+// so its deadline, values and trace task are passed on by Job.Context.
+func WithJobContext(ctx context.Context) JobOption {
+    return func(cfg *jobConfig) {
+        cfg.ctx = ctx
+    }
+}
+
+// lock locks the calling goroutine to its thread if configured.
+// The returned function undoes the lock.
+func (cfg *jobConfig) lock() (unlock func()) {
+    if !cfg.lockThread {
+        return func() {}
+    }
+    runtime.LockOSThread()
+    return runtime.UnlockOSThread
+}
+
+// An Option configures a jobhandler created by New.
+type Option func(*JobHandler)
+
+// WithName names the jobhandler. The name is included in panic messages
+// and prefixes the names of child jobhandlers, see NewChild.
+func WithName(name string) Option {
+    return func(jh *JobHandler) {
+        jh.name = name
+    }
+}
+
+// WithMaxTotalJobs bounds the number of jobs the jobhandler accepts
+// over its lifetime to n. Once n jobs are accepted, the jobhandler is
+// stopped with ErrMaxTotalJobs as stop cause, after the pre-stop delay
+// if any, see WithPreStopDelay, and drains as usual.
+// Jobs that would exceed n are rejected. A n <= 0 does not bound the jobs.
+func WithMaxTotalJobs(n int64) Option {
+    return func(jh *JobHandler) {
+        jh.maxTotal = n
+    }
 }
 
 // Create a new job handler
-// The jobhandler is stopped when the passed context is done.
-func New(ctx context.Context) *JobHandler {
-    jh := JobHandler{
-        n:        1,
-        stopChan: make(chan struct{}),
-    }
-    jh.running.Store(true)
-    jh.wg.Add(1)
+// The jobhandler is stopped when the passed context is done,
+// with the cause of ctx as stop cause, see StopCause.
+func New(ctx context.Context, opts ...Option) *JobHandler {
+    jh := &JobHandler{}
+    for _, opt := range opts {
+        opt(jh)
+    }
+    jh.buildPipeline()
+    jh.setUnbounded()
+    jh.loadMarker()
+    jh.start(ctx)
+    return jh
+}
+
+// start arms the jobhandler to take on jobs until it is stopped
+// or ctx is done.
+func (jh *JobHandler) start(ctx context.Context) {
+    jh.parent = ctx
+    jh.stopChan = make(chan struct{})
+    jh.announceChan = make(chan struct{})
+    jh.announced.Store(false)
+    jh.ctx, jh.cancel = context.WithCancelCause(context.Background())
+    jh.forceCtx, jh.forceCancel = context.WithCancelCause(context.Background())
+    jh.critical.Store(jh.criticalMax)
+    jh.isDrained.Store(false)
+    atomic.StoreInt64(&jh.n, runningBit)
+    jh.drainedChan = make(chan struct{})
+    jh.stoppedChan = make(chan struct{})
     if ctx != nil && ctx.Done() != nil {
+        stopChan := jh.stopChan
         go func() {
             select {
             case <-ctx.Done():
-                jh.Stop()
-            case <-jh.stopChan:
+                jh.stopAfterDelay(context.Cause(ctx))
+            case <-stopChan:
             }
         }()
     }
-    return &jh
+    jh.notifySignals()
+    jh.startProbes()
+    jh.startWatchdog()
+}
+
+// Reset re-arms a stopped jobhandler so it takes on jobs again,
+// as if created anew by New with the same context and options.
+// The counters of Stats, Accepted and Rejected are kept, and the
+// shutdown marker, see WithShutdownMarker, is removed again.
+// Reset panics unless WaitAll has returned, and must not be called
+// concurrently with other methods of the jobhandler.
+// Reset also panics after ForceStop, as the abandoned jobs may still
+// be running and their Done calls would land in the new run.
+func (jh *JobHandler) Reset() {
+    if jh.stoppedChan != nil {
+        select {
+        case <-jh.stoppedChan:
+        default:
+            jh.panic("Reset before WaitAll returned")
+        }
+    }
+    if jh.forced.Load() {
+        jh.panic("Reset after ForceStop")
+    }
+    if jh.marker != nil {
+        if err := jh.marker.Store(false); err != nil {
+            jh.log(slog.LevelError, "jobhandler: removing shutdown marker", "error", err)
+        }
+    }
+    jh.mu.Lock()
+    jh.jobs = nil
+    jh.state = Running
+    jh.hooksRun = false
+    jh.values = nil
+    jh.closers = nil
+    jh.abandoned = 0
+    jh.abandonedGroups = nil
+    jh.graceDeadline = time.Time{}
+    jh.drainTask = nil
+    jh.phases = nil
+    jh.phasesStopping = false
+    jh.stopFuncsRun = false
+    jh.mu.Unlock()
+    jh.stopRequested.Store(false)
+    jh.preStopping.Store(false)
+    jh.total.Store(0)
+    jh.start(jh.parent)
+}
+
+// AlsoStopOn makes the jobhandler also stop when ctx is done,
+// with the cause of ctx as stop cause, like the context passed to New,
+// e.g. a kill switch context of a feature.
+func (jh *JobHandler) AlsoStopOn(ctx context.Context) {
+    if jh.Stopped() {
+        return
+    }
+    stop := context.AfterFunc(ctx, func() {
+        jh.stopAfterDelay(context.Cause(ctx))
+    })
+    jh.OnStopFunc(func() { stop() })
+}
+
+// NewChild creates a jobhandler that is stopped when jh is stopped.
+// The child is named by appending name to the name of jh as a dotted path,
+// e.g. "server.ingest.tenant42".
+// The child counts as a single job of jh until the child is stopped
+// and all of its jobs are done, so jh.WaitAll also waits for the child.
+// If jh is stopped, the returned child is stopped as well.
+func (jh *JobHandler) NewChild(name string, opts ...Option) *JobHandler {
+    if jh.name != "" {
+        name = jh.name + "." + name
+    }
+    child := New(jh.Context(), append(opts[:len(opts):len(opts)], WithName(name))...)
+    jh.adopt(child)
+    return child
+}
+
+// adopt makes child count as a single job of jh until child is drained.
+// If jh is stopped, child is stopped as well.
+func (jh *JobHandler) adopt(child *JobHandler) {
+    if !jh.Try() {
+        child.Stop()
+        return
+    }
+    jh.mu.Lock()
+    jh.children = append(jh.children, child)
+    jh.mu.Unlock()
+    go func() {
+        child.WaitAll()
+        jh.mu.Lock()
+        jh.children = slices.DeleteFunc(jh.children, func(c *JobHandler) bool {
+            return c == child
+        })
+        jh.mu.Unlock()
+        jh.Done()
+    }()
 }
 
 // Attempt to take on a single job.
@@ -46,47 +407,393 @@ func (jh *JobHandler) Try() bool {
     return jh.TryN(1)
 }
 
+// TryCtx is like TryJob with WithJobContext(ctx): the context of the job
+// is cancelled when the deadline of ctx passes, so the work can give up.
+// Returns the job and true if it is successfully taken
+// and false if the JobHandler is stopped or ctx is already done.
+// The job stays outstanding until its Done method is called, so WaitAll
+// waits for the work rather than for ctx. A job whose context deadline
+// passed before it is done is counted as expired in Stats.
+func (jh *JobHandler) TryCtx(ctx context.Context) (*Job, bool) {
+    if ctx.Err() != nil {
+        return nil, jh.reject("context done")
+    }
+    j, ok := jh.TryJob(WithJobContext(ctx))
+    if ok {
+        j.expires = true
+    }
+    return j, ok
+}
+
+// TryFuncCtx is like TryFunc, but binds the job to ctx.
+// fn is passed a context that is cancelled when ctx is done or
+// the jobhandler is stopped, including when it is force stopped.
+// Pass context.Background() to only observe the jobhandler.
+// Returns true if job is successfully taken
+// and false if the JobHandler is stopped or ctx is already done.
+// The job is flagged as done after fn returns, and counted as expired
+// in Stats if the deadline of ctx passed.
+func (jh *JobHandler) TryFuncCtx(ctx context.Context, fn func(context.Context), opts ...JobOption) bool {
+    if ctx.Err() != nil {
+        return jh.reject("context done")
+    }
+    return jh.TryFunc(func() {
+        jobCtx, cancel := jh.withStop(ctx)
+        defer cancel()
+        fn(jobCtx)
+        jh.checkExpired(ctx)
+    }, opts...)
+}
+
+// TryFuncTimeout is like TryFuncCtx, but fn is passed a context that
+// is cancelled d after fn is called, or when the jobhandler is stopped.
+// A job that runs past d is counted as expired in Stats.
+// Note that the job is only flagged as done after fn returns,
+// so fn must give up once its context is done.
+func (jh *JobHandler) TryFuncTimeout(d time.Duration, fn func(context.Context), opts ...JobOption) bool {
+    return jh.TryFunc(func() {
+        ctx, cancel := context.WithTimeout(context.Background(), d)
+        defer cancel()
+        jobCtx, stop := jh.withStop(ctx)
+        defer stop()
+        fn(jobCtx)
+        jh.checkExpired(ctx)
+    }, opts...)
+}
+
+// checkExpired counts a job as expired if the deadline of its context passed.
+func (jh *JobHandler) checkExpired(ctx context.Context) {
+    if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+        jh.expired.Add(1)
+    }
+}
+
 // TryFunc is a convenience function that combines Try() and Done().
 // Returns true if job is successfully taken
 // and false if the JobHandler is stopped.
 // Do not call Done(), the job is automatically
 // flagged as done after fn exits.
-func (jh *JobHandler) TryFunc(fn func()) bool {
+func (jh *JobHandler) TryFunc(fn func(), opts ...JobOption) bool {
     if !jh.Try() {
         return false
     }
-    fn()
+    cfg := newJobConfig(opts)
+    unlock := cfg.lock()
+    jh.runJob("", fn)
+    unlock()
     jh.Done()
     return true
 }
+// TrySplit carves total jobs into batches of no more than maxPerBatch jobs
+// and runs fn with the indices of each batch in turn, taking the jobs
+// of a batch with TryN before running it. It stops before the first batch
+// that cannot be taken, e.g. because the jobhandler is stopped or its
+// budget is exhausted. The batch slice is reused between calls.
+// Returns the number of jobs run, which is 0 if total is not positive.
+// Do not call Done(), the jobs are automatically
+// flagged as done after fn exits.
+func (jh *JobHandler) TrySplit(total, maxPerBatch int, fn func(batch []int)) int {
+    if total <= 0 {
+        return 0
+    }
+    if maxPerBatch <= 0 {
+        maxPerBatch = total
+    }
+    batch := make([]int, 0, min(total, maxPerBatch))
+    for start := 0; start < total; start += maxPerBatch {
+        end := min(start + maxPerBatch, total)
+        if !jh.TryN(end - start) {
+            return start
+        }
+        batch = batch[:0]
+        for i := start; i < end; i++ {
+            batch = append(batch, i)
+        }
+        jh.runJob("", func() { fn(batch) })
+        jh.doneN(end - start, "")
+    }
+    return total
+}
+
 // TryN attempts to take on multiple jobs.
 // Either all jobs are taken or none are taken.
 // Returns true if the jobs are successfully taken
 // and false if the JobHandler is stopped.
 // Done must be called for each of the delta jobs taken.
 func (jh *JobHandler) TryN(delta int) bool {
+    return jh.tryN(delta, "")
+}
+
+// tryN takes on delta jobs of the job class class, which may be empty.
+func (jh *JobHandler) tryN(delta int, class string) bool {
+    if reason := jh.admit(delta, class); reason != "" {
+        return jh.reject(reason)
+    }
+    return true
+}
+
+// Rejection reasons of admit that are due to a lack of capacity,
+// which may be freed by jobs that are done, see AcquireN.
+const (
+    reasonBudget     = "budget exhausted"
+    reasonConcurrent = "max concurrent jobs"
+)
+
+// admit takes on delta jobs of the job class class, which may be empty.
+// Returns the reason the jobs are rejected, or "" if they are taken.
+func (jh *JobHandler) admit(delta int, class string) string {
     if delta < 0 {
-        return false
+        return "negative job count"
     }
-    if !jh.running.Load() {
-        return false
+    if jh.unbounded.Load() && class == "" {
+        return jh.admitUnbounded(delta)
+    }
+    if jh.Stopped() {
+        return "stopped"
+    }
+    if jh.paused.Load() {
+        return "paused"
+    }
+    if class != "" && jh.gated(class) {
+        return "gate closed"
+    }
+    a := Admission{Delta: delta, Class: class}
+    if err := jh.admitStages(a); err != nil {
+        return err.Error()
     }
     for {
         prev := atomic.LoadInt64(&jh.n)
-        if prev < 0 {
-            panic("negative job count")
+        if prev < runningBit {
+            jh.undoStages(a, len(jh.pipeline))
+            return "stopped"
         }
-        if prev == 0 {
-            return false
+        if limit := jh.maxConcurrent.Load(); limit > 0 && prev&^runningBit + int64(delta) > limit {
+            jh.undoStages(a, len(jh.pipeline))
+            return reasonConcurrent
         }
         if atomic.CompareAndSwapInt64(&jh.n, prev, prev + int64(delta)) {
             break
         }
     }
-    jh.wg.Add(delta)
+    jh.accepted.Add(uint64(delta))
+    if jh.maxTotal > 0 && jh.total.Load() >= jh.maxTotal {
+        if jh.preStopDelay > 0 {
+            // Admission must not block during the pre-stop delay
+            go jh.stopAfterDelay(ErrMaxTotalJobs)
+        } else {
+            jh.stop(ErrMaxTotalJobs)
+        }
+    }
+    return ""
+}
+
+// admitUnbounded is the fast path of admit for a running jobhandler
+// without admission stages or bounds, which only adds delta to the job count,
+// a single atomic operation, instead of looping on a compare and swap.
+func (jh *JobHandler) admitUnbounded(delta int) string {
+    if atomic.AddInt64(&jh.n, int64(delta)) >= runningBit {
+        // Stop clears runningBit in the same count, so the jobs were
+        // counted before the jobhandler was stopped
+        jh.accepted.Add(uint64(delta))
+        return ""
+    }
+    // The jobhandler is stopped, and may have been drained,
+    // so the jobs are not taken
+    jh.settle(atomic.AddInt64(&jh.n, -int64(delta)), delta)
+    return "stopped"
+}
+
+const (
+    // runningBit is set in the job count while the jobhandler is running,
+    // so that taking jobs and checking that it is running is a single add.
+    runningBit int64 = 1 << 62
+    // drainedBit is set in the job count once the jobhandler is drained,
+    // so that jobs raising the count after the drain, like admitUnbounded
+    // while stopped, cannot drain it again or let TryCritical admit a job.
+    drainedBit int64 = 1 << 61
+    // lateBit is set in a job count lowered below drainedBit by Done calls
+    // of jobs abandoned by ForceStop. Job counts never reach it.
+    lateBit int64 = 1 << 60
+)
+
+// settle checks the job count n after delta jobs were subtracted
+// from a stopped jobhandler. It drains the jobhandler if n is zero and
+// undoes the subtraction if the jobs were abandoned by ForceStop.
+// Returns false if the jobs were abandoned.
+func (jh *JobHandler) settle(n int64, delta int) bool {
+    switch {
+    case n == 0:
+        if atomic.CompareAndSwapInt64(&jh.n, 0, drainedBit) {
+            jh.drained()
+        }
+    case n < 0:
+        jh.panic("negative job count")
+    case n >= drainedBit|lateBit && n < runningBit:
+        // Lowered below runningBit while running
+        jh.panic("zero job count while running, should be at least 1")
+    case abandoned(n):
+        if !jh.forced.Load() {
+            jh.panic("negative job count")
+        }
+        atomic.AddInt64(&jh.n, int64(delta))
+        jh.lateDone.Add(uint64(delta))
+        return false
+    }
     return true
 }
 
+// abandoned returns true if the job count n was lowered below drainedBit
+// by Done calls of jobs abandoned by ForceStop.
+func abandoned(n int64) bool {
+    return n >= lateBit && n < drainedBit
+}
+
+// AcquireN is like TryN, but if the jobs do not fit the bounds of
+// WithMaxConcurrent or WithBudget, it blocks until they fit,
+// ctx is done or the jobhandler is stopped.
+// Returns true if the jobs are taken and false if not.
+func (jh *JobHandler) AcquireN(ctx context.Context, delta int) bool {
+    defer jh.waitFor("AcquireN")()
+    jh.capacity.waiters.Add(1)
+    defer jh.capacity.waiters.Add(-1)
+    var budget <-chan struct{}
+    if jh.budget != nil {
+        jh.budget.capacity.waiters.Add(1)
+        defer jh.budget.capacity.waiters.Add(-1)
+    }
+    for {
+        freed := jh.capacity.wait()
+        if jh.budget != nil {
+            budget = jh.budget.capacity.wait()
+        }
+        reason := jh.admit(delta, "")
+        if reason == "" {
+            return true
+        }
+        if reason != reasonBudget && reason != reasonConcurrent {
+            return jh.reject(reason)
+        }
+        select {
+        case <-freed:
+        case <-budget:
+        case <-ctx.Done():
+            return jh.reject("context done")
+        case <-jh.stopCh():
+            return jh.reject("stopped")
+        }
+    }
+}
+
+// Acquire is like AcquireN for a single job.
+func (jh *JobHandler) Acquire(ctx context.Context) bool {
+    return jh.AcquireN(ctx, 1)
+}
+
+// WithMaxConcurrent bounds the number of jobs that are not done to n.
+// Jobs that would exceed n are rejected. A n <= 0 does not bound the jobs.
+// Critical jobs, see TryCritical, are only exempt from the bound once
+// the jobhandler is stopped.
+func WithMaxConcurrent(n int64) Option {
+    return func(jh *JobHandler) {
+        jh.maxConcurrent.Store(n)
+    }
+}
+
+// SetMaxConcurrent changes the bound of WithMaxConcurrent to n,
+// e.g. from an admin endpoint. Jobs that are already taken are not
+// affected, and goroutines blocked in AcquireN and queued jobs,
+// see WithQueue, are woken to retry.
+func (jh *JobHandler) SetMaxConcurrent(n int64) {
+    jh.maxConcurrent.Store(n)
+    jh.setUnbounded()
+    jh.capacity.notify()
+}
+
+// MaxConcurrent returns the bound set by WithMaxConcurrent or
+// SetMaxConcurrent, or 0 if the jobs are not bounded.
+func (jh *JobHandler) MaxConcurrent() int64 {
+    return max(jh.maxConcurrent.Load(), 0)
+}
+
+// setUnbounded enables the fast path of admit if the jobhandler
+// has no admission stages or bounds.
+func (jh *JobHandler) setUnbounded() {
+    jh.unbounded.Store(len(jh.pipeline) == 0 && len(jh.probes) == 0 && jh.maxConcurrent.Load() <= 0)
+}
+
+// WithCriticalJobs allows up to n jobs to be taken with TryCritical
+// after the jobhandler is stopped.
+func WithCriticalJobs(n int64) Option {
+    return func(jh *JobHandler) {
+        jh.criticalMax = n
+    }
+}
+
+// TryCritical attempts to take on a single job like Try,
+// but is still admitted while the jobhandler drains, for must-run cleanup
+// like writing a shutdown marker or flushing a WAL. After stop, no more than
+// the number of jobs set by WithCriticalJobs are admitted, and none after
+// the grace deadline, see StopWithTimeout, or once all jobs are done.
+// Critical jobs are drawn from the budget of the jobhandler, see WithBudget,
+// even if it is exhausted, and forced through the other admission stages,
+// see Admission.
+// When the job is done call the Done() method.
+func (jh *JobHandler) TryCritical() bool {
+    return jh.tryCritical("")
+}
+
+// tryCritical is TryCritical for a job of the job class class,
+// which may be empty.
+func (jh *JobHandler) tryCritical(class string) bool {
+    if !jh.Stopped() {
+        return jh.tryN(1, class)
+    }
+    if jh.critical.Add(-1) < 0 {
+        return jh.reject("critical jobs exhausted")
+    }
+    if deadline, ok := jh.GraceDeadline(); ok && !jh.clock().Now().Before(deadline) {
+        return jh.reject("grace deadline passed")
+    }
+    for {
+        // The drain sets drainedBit, so a count raised after it
+        // is not mistaken for outstanding jobs
+        prev := atomic.LoadInt64(&jh.n)
+        if prev <= 0 || prev >= lateBit {
+            return jh.reject("drained")
+        }
+        if atomic.CompareAndSwapInt64(&jh.n, prev, prev + 1) {
+            break
+        }
+    }
+    jh.admitStages(Admission{Delta: 1, Class: class, Forced: true})
+    jh.accepted.Add(1)
+    return true
+}
+
+// reserveTotal reserves delta of the jobs bounded by WithMaxTotalJobs.
+// Returns false if the jobs would exceed the bound.
+func (jh *JobHandler) reserveTotal(delta int) bool {
+    if jh.maxTotal <= 0 {
+        return true
+    }
+    for {
+        prev := jh.total.Load()
+        if prev + int64(delta) > jh.maxTotal {
+            return false
+        }
+        if jh.total.CompareAndSwap(prev, prev + int64(delta)) {
+            return true
+        }
+    }
+}
+
+// unreserveTotal undoes reserveTotal for jobs that were not taken.
+func (jh *JobHandler) unreserveTotal(delta int) {
+    if jh.maxTotal > 0 {
+        jh.total.Add(-int64(delta))
+    }
+}
+
 // TryFuncAsync is a convenience function that
 // combines Try() and Done() and runs the function asynchronously.
 // Returns a read-only channel that sends a boolean value.
@@ -94,14 +801,22 @@ func (jh *JobHandler) TryN(delta int) bool {
 // If the jobhandler is stopped, the channel sends false.
 // Do not call Done(), the job is automatically
 // flagged as done after fn exits.
-func (jh *JobHandler) TryFuncAsync(fn func()) <-chan bool {
+func (jh *JobHandler) TryFuncAsync(fn func(), opts ...JobOption) <-chan bool {
+    return jh.tryFuncAsync(fn, nil, opts)
+}
+
+// tryFuncAsync is TryFuncAsync, running fallback, if not nil,
+// asynchronously if the job is rejected.
+func (jh *JobHandler) tryFuncAsync(fn, fallback func(), opts []JobOption) <-chan bool {
     ch := make(chan bool, 1)
     if !jh.Try() {
-        ch <- false
+        jh.fallback(fallback, ch)
         return ch
     }
+    cfg := newJobConfig(opts)
     go func() {
-        fn()
+        defer cfg.lock()()
+        jh.runJob("", fn)
         jh.Done()
         ch <- true
     }()
@@ -113,52 +828,178 @@ func (jh *JobHandler) TryFuncAsync(fn func()) <-chan bool {
 // Either all jobs are taken or none are taken.
 // Func fn is called delta times with the job index,
 // 0 in the first call delta-1 in the final call.
-// A goroutine is spawned for each call, but no more than
-// limit at a time. If limit is <= 0, it is set to delta.
+// The calls are spread across limit worker goroutines,
+// each making one call at a time. If limit is <= 0, it is set to delta.
 // The fn is not guaranteed to be called in order.
 // If the job is successfully taken, the channel sends true.
 // If the jobhandler is stopped, the channel sends false.
 // Do not call Done(), the jobs are automatically
 // flagged as done after the fns exit.
-func (jh *JobHandler) TryNFuncAsync(delta, limit int, fn func (int)) <-chan bool {
+func (jh *JobHandler) TryNFuncAsync(delta, limit int, fn func (int), opts ...JobOption) <-chan bool {
+    return jh.tryNFuncAsync(delta, limit, fn, nil, nil, opts)
+}
+
+// tryNFuncAsync is TryNFuncAsync, running fallback, if not nil,
+// asynchronously if the jobs are rejected, and release, if not nil,
+// once all workers have returned.
+func (jh *JobHandler) tryNFuncAsync(delta, limit int, fn func (int), fallback, release func(), opts []JobOption) <-chan bool {
     ch := make(chan bool, 1)
     if !jh.TryN(delta) {
-        ch <- false
+        jh.fallback(fallback, ch)
         return ch
     }
-    // Note: Replace channels with semaphores
-    // when they released into standard library
-    if limit <= 0 {limit = delta }
-    var limitCh chan struct{}
-    if limit < delta {
-        limitCh = make(chan struct{}, limit)
-        for i := 0; i < limit; i++ {
-            limitCh <- struct{}{}
+    cfg := newJobConfig(opts)
+    if limit <= 0 || limit > delta { limit = delta }
+    var order []int
+    if cfg.seeded {
+        seed := cfg.seed
+        for seed == 0 {
+            seed = rand.Int63()
         }
+        jh.log(slog.LevelInfo, "jobhandler: order seed", "seed", seed)
+        order = rand.New(rand.NewSource(seed)).Perm(delta)
     }
-    go func() {
-        for i := 0; i < delta; i++ {
-            if limit < delta { <-limitCh }
-            go func() {
-                fn(i)
+    // Paced jobs are never released all at once on stop
+    skipOnStop := cfg.skipOnStop || cfg.pace > 0
+    var next, workers atomic.Int64
+    workers.Store(int64(limit))
+    start := jh.clock().Now()
+    for w := 0; w < limit; w++ {
+        go jh.runJob("", func() {
+            if release != nil {
+                defer func() {
+                    if workers.Add(-1) == 0 {
+                        release()
+                    }
+                }()
+            }
+            defer cfg.lock()()
+            for {
+                if skipOnStop && jh.Stopped() {
+                    // Claim all remaining indices at once
+                    if i := int(next.Swap(int64(delta))); i < delta {
+                        jh.skip(delta - i, cfg.onSkip)
+                    }
+                    return
+                }
+                i := int(next.Add(1)) - 1
+                if i >= delta {
+                    return
+                }
+                if !jh.pace(start, cfg.pace, i, delta) {
+                    // Skip the claimed index and claim all remaining ones
+                    n := 1
+                    if j := int(next.Swap(int64(delta))); j < delta {
+                        n += delta - j
+                    }
+                    jh.skip(n, cfg.onSkip)
+                    return
+                }
+                if order != nil {
+                    i = order[i]
+                }
+                jh.runIndex(fn, i)
                 jh.Done()
-                if limit < delta { limitCh <- struct{}{} }
-            }()
-        }
-    }()
+            }
+        })
+    }
     ch <- true
     return ch
 }
 
+// pace waits until job i of delta jobs paced over d since start is due.
+// Returns false if the jobhandler was stopped while waiting.
+func (jh *JobHandler) pace(start time.Time, d time.Duration, i, delta int) bool {
+    if d <= 0 {
+        return true
+    }
+    due := start.Add(time.Duration(int64(d) * int64(i) / int64(delta)))
+    if wait := due.Sub(jh.clock().Now()); wait > 0 {
+        return jh.TrySleep(wait)
+    }
+    return true
+}
+
+// TryFuncCtxAsync is like TryFuncAsync, but binds the job to ctx
+// like TryFuncCtx.
+func (jh *JobHandler) TryFuncCtxAsync(ctx context.Context, fn func(context.Context), opts ...JobOption) <-chan bool {
+    if ctx.Err() != nil {
+        ch := make(chan bool, 1)
+        ch <- jh.reject("context done")
+        return ch
+    }
+    return jh.TryFuncAsync(func() {
+        jobCtx, cancel := jh.withStop(ctx)
+        defer cancel()
+        fn(jobCtx)
+        jh.checkExpired(ctx)
+    }, opts...)
+}
+
+// TryNFuncCtxAsync is like TryNFuncAsync, but binds the jobs to ctx
+// like TryFuncCtx. All calls of fn share the same context.
+func (jh *JobHandler) TryNFuncCtxAsync(ctx context.Context, delta, limit int, fn func(context.Context, int), opts ...JobOption) <-chan bool {
+    if ctx.Err() != nil {
+        ch := make(chan bool, 1)
+        ch <- jh.reject("context done")
+        return ch
+    }
+    jobCtx, cancel := jh.withStop(ctx)
+    // The context is released once all calls of fn returned, were skipped
+    // or panicked, or by the fallback if the jobs are rejected
+    ch := jh.tryNFuncAsync(delta, limit, func (i int) {
+        fn(jobCtx, i)
+        jh.checkExpired(ctx)
+    }, cancel, cancel, opts)
+    if delta == 0 {
+        cancel()
+    }
+    return ch
+}
+
+// skip flags n batch jobs that were never started as done.
+func (jh *JobHandler) skip(n int, report func(int)) {
+    jh.skipped.Add(uint64(n))
+    jh.doneN(n, "")
+    if report != nil {
+        report(n)
+    }
+}
+
 // TrySleep attempts to sleep duration d. The sleep is cancelled
 // if the jobhandler is stopped. Returns true if sleep was
 // done. Returns false if jobhandler was stopped before
-// the sleep was done.
+// the sleep was done, immediately if it is already stopped.
 func (jh *JobHandler) TrySleep(d time.Duration) bool {
+    if jh.Stopped() {
+        return false
+    }
+    t := jh.clock().NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-jh.stopCh():
+        return false
+    case <-t.C():
+        return true
+    }
+}
+
+// TrySleepCtx is like TrySleep, but the sleep is also cancelled
+// if ctx is done, e.g. when an individual job is cancelled.
+// Returns false if the jobhandler was stopped or ctx was done
+// before the sleep was done.
+func (jh *JobHandler) TrySleepCtx(ctx context.Context, d time.Duration) bool {
+    if jh.Stopped() {
+        return false
+    }
+    t := jh.clock().NewTimer(d)
+    defer t.Stop()
     select {
-    case <-jh.stopChan:
+    case <-jh.stopCh():
         return false
-    case <-time.After(d):
+    case <-ctx.Done():
+        return false
+    case <-t.C():
         return true
     }
 }
@@ -167,41 +1008,198 @@ func (jh *JobHandler) TrySleep(d time.Duration) bool {
 // Note that Done must not be called when using TryFunc, TryFuncAsync
 // and TryNFuncAsync. as the job is automatically flagged as done for these functions.
 func (jh *JobHandler) Done() {
-    if n := atomic.AddInt64(&jh.n, -1); n < 0 {
-        panic("negative job count")
-    } else if n == 0 && jh.running.Load() {
-        panic("zero job count while running, should be at least 1")
+    jh.doneN(1, "")
+}
+
+// doneN flags delta jobs of the job class class, which may be empty, as done.
+func (jh *JobHandler) doneN(delta int, class string) {
+    n := atomic.AddInt64(&jh.n, -int64(delta))
+    if abandoned(n) {
+        jh.settle(n, delta)
+        return
+    }
+    // Release the jobs before a drain runs the shutdown hooks
+    jh.releaseStages(Admission{Delta: delta, Class: class})
+    jh.capacity.notify()
+    if n < runningBit {
+        jh.settle(n, delta)
     }
-    jh.wg.Add(-1)
 }
 
 // WaitAll blocks until all jobs are done and the jobhandler is stopped.
 // WaitAll is typically used to wait for a graceful shutdowns, and is
 // in that case either in the main function or followed by os.Exit(0).
 func (jh *JobHandler) WaitAll() {
-    jh.wg.Wait()
+    jh.waitStopped("WaitAll")
 }
 
 // Stop a jobhandler.
 // Returns true if stop is initiated. Returns false if already stopped.
+// Stop is delayed if the jobhandler is created with WithPreStopDelay.
 func (jh *JobHandler) Stop() bool {
-    if !jh.running.CompareAndSwap(true, false) {
-        return false
+    return jh.stopAfterDelay(ErrStopped)
+}
+
+// StopWithError stops the jobhandler with err as stop cause, so consumers
+// can learn why it stopped through Err.
+// Returns true if stop is initiated. Returns false if already stopped.
+// If err is nil, ErrStopped is used.
+func (jh *JobHandler) StopWithError(err error) bool {
+    if err == nil {
+        err = ErrStopped
     }
-    if atomic.AddInt64(&jh.n, -1) < 0 {
-        panic("negative job count")
+    return jh.stopAfterDelay(err)
+}
+
+// stop stops the jobhandler with the stop cause cause.
+func (jh *JobHandler) stop(cause error) bool {
+    for {
+        // Swap runningBit for a placeholder job, which keeps the jobhandler
+        // from draining until it is stopped
+        prev := atomic.LoadInt64(&jh.n)
+        if prev < runningBit {
+            return false
+        }
+        if atomic.CompareAndSwapInt64(&jh.n, prev, prev - runningBit + 1) {
+            break
+        }
     }
+    jh.setState(Draining)
+    jh.announce()
     close(jh.stopChan)
-    jh.wg.Add(-1)
+    jh.cancel(cause)
+    jh.runStopFuncs()
+    jh.stopQueue()
+    jh.traceDrain()
+    n := atomic.AddInt64(&jh.n, -1)
+    jh.log(slog.LevelInfo, "jobhandler: stopping", "cause", cause, "jobs", n)
+    jh.settle(n, 1)
     return true
 }
 
 // IsStopd returns true if jobhandler is stopped and false if not.
+// Stopped is a single atomic load, so long CPU-bound loops may poll it
+// cheaply to learn that a drain has begun.
 func (jh *JobHandler) Stopped() bool {
-    return !jh.running.Load()
+    return atomic.LoadInt64(&jh.n) < runningBit
+}
+
+// Yield is a cooperative yield point for long running jobs.
+// It lets other goroutines run, see runtime.Gosched, and then returns
+// the stop cause if the jobhandler is stopped, the error of ctx if ctx
+// is done, and nil otherwise. ctx may be nil.
+//
+//     for _, item := range items {
+//         if err := jh.Yield(context.Background()); err != nil {
+//             return err
+//         }
+//         process(item)
+//     }
+func (jh *JobHandler) Yield(ctx context.Context) error {
+    runtime.Gosched()
+    if jh.Stopped() {
+        return jh.StopCause()
+    }
+    if ctx != nil {
+        return ctx.Err()
+    }
+    return nil
+}
+
+// Stats returns a snapshot of the jobhandler's counters.
+func (jh *JobHandler) Stats() Stats {
+    return Stats{
+        Expired:  jh.expired.Load(),
+        Skipped:  jh.skipped.Load(),
+        LateDone: jh.lateDone.Load(),
+        Dropped:  jh.dropped.Load(),
+        SLOs:     jh.sloStats(),
+        Jobs:     jh.jobTimesStats(),
+    }
+}
+
+// Running returns the number of jobs that are not done.
+func (jh *JobHandler) Running() int64 {
+    return jh.inFlight()
 }
 
-// OnStop returns a channel that's closed when jobhandler is stopped.
+// Accepted returns the number of jobs taken since the jobhandler was created.
+func (jh *JobHandler) Accepted() uint64 {
+    return jh.accepted.Load()
+}
+
+// Rejected returns the number of attempts to take on jobs that failed
+// since the jobhandler was created. A rejected TryN counts once.
+func (jh *JobHandler) Rejected() uint64 {
+    return jh.rejected.Load()
+}
+
+// Name returns the name of the jobhandler.
+func (jh *JobHandler) Name() string {
+    return jh.name
+}
+
+// panic panics with msg prefixed by the jobhandler name, if any.
+func (jh *JobHandler) panic(msg string) {
+    if jh.name != "" {
+        msg = "jobhandler " + jh.name + ": " + msg
+    }
+    panic(msg)
+}
+
+// StopCause returns why the jobhandler is stopped, or nil if it is running.
+// If the jobhandler is stopped because the context passed to New is done,
+// the cause of that context is returned, see context.Cause.
+// Otherwise ErrStopped is returned.
+func (jh *JobHandler) StopCause() error {
+    return context.Cause(jh.Context())
+}
+
+// Err returns why the jobhandler is stopped, or nil if it is running.
+// It is the same as StopCause.
+func (jh *JobHandler) Err() error {
+    return jh.StopCause()
+}
+
+// Context returns a context that is cancelled when jobhandler is stopped,
+// with the stop cause as cancellation cause, see StopCause.
+// Pass it to context-aware APIs instead of threading both the
+// context passed to New and the jobhandler around.
+func (jh *JobHandler) Context() context.Context {
+    if jh.ctx == nil {
+        return canceledCtx
+    }
+    return jh.ctx
+}
+
+// withStop returns a copy of ctx that is also cancelled when jobhandler is stopped,
+// with the stop cause as cancellation cause.
+func (jh *JobHandler) withStop(ctx context.Context) (context.Context, context.CancelFunc) {
+    ctx, cancel := context.WithCancelCause(ctx)
+    stop := context.AfterFunc(jh.Context(), func() {
+        cancel(jh.StopCause())
+    })
+    return ctx, func() {
+        stop()
+        cancel(nil)
+    }
+}
+
+// OnStop returns a channel that's closed when jobhandler is stopped,
+// or when its shutdown is announced by Drain.
+// The channel of a zero jobhandler is closed.
 func (jh *JobHandler) OnStop() <-chan struct{} {
+    if jh.announceChan == nil {
+        return jh.stopCh()
+    }
+    return jh.announceChan
+}
+
+// stopCh returns a channel that's closed when jobhandler is stopped.
+// Unlike stopChan, it is closed for a zero jobhandler instead of nil.
+func (jh *JobHandler) stopCh() <-chan struct{} {
+    if jh.stopChan == nil {
+        return canceledCtx.Done()
+    }
     return jh.stopChan
 }
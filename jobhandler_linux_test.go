@@ -0,0 +1,59 @@
+package jobhandler
+import(
+    "context"
+    "runtime"
+    "sync"
+    "syscall"
+    "testing"
+    "time"
+)
+
+func TestWithLockedThreadTid(t *testing.T) {
+    jh := New(context.Background())
+    // stays reports whether the job keeps its thread across yields and sleeps
+    stays := func () bool {
+        tid := syscall.Gettid()
+        for i := 0; i < 10; i++ {
+            runtime.Gosched()
+            time.Sleep(100 * time.Microsecond)
+            if syscall.Gettid() != tid {
+                return false
+            }
+        }
+        return true
+    }
+    var (
+        mu    sync.Mutex
+        moved int
+        tids  = make(map[int]bool)
+    )
+    check := func () {
+        if !stays() {
+            mu.Lock()
+            moved++
+            mu.Unlock()
+        }
+    }
+    var barrier sync.WaitGroup
+    barrier.Add(4)
+    async := jh.TryFuncAsync(check, WithLockedThread())
+    <-jh.TryNFuncAsync(4, 4, func (i int) {
+        barrier.Done()
+        barrier.Wait()
+        // The workers run at once, so each must hold its own thread
+        mu.Lock()
+        tids[syscall.Gettid()] = true
+        mu.Unlock()
+        check()
+    }, WithLockedThread())
+    jh.TryFunc(check, WithLockedThread())
+    <-async
+    jh.Stop()
+    jh.WaitAll()
+    if moved != 0 {
+        t.Fatal("locked jobs moved between threads", moved)
+    }
+    if len(tids) != 4 {
+        t.Fatal("concurrent locked workers shared threads", len(tids))
+    }
+}
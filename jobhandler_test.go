@@ -1,7 +1,12 @@
 package jobhandler
 import(
     "context"
+    "errors"
+    "log/slog"
     "slices"
+    "strconv"
+    "strings"
+    "sync"
     "sync/atomic"
     "testing"
     "time"
@@ -15,6 +20,15 @@ func TestZeroHandler(t *testing.T) {
     if !jh.Stopped() {
         t.Fatal("zero handler should be stopped")
     }
+    select {
+    case <-jh.OnStop():
+    default:
+        t.Fatal("OnStop of zero handler should be closed")
+    }
+    if jh.TrySleep(time.Hour) || jh.TrySleepCtx(context.Background(), time.Hour) ||
+        jh.TrySleepNamed("poll", time.Hour) {
+        t.Fatal("zero handler should not sleep")
+    }
     jh.WaitAll()
     jh.Stop()
     jh.WaitAll()
@@ -141,6 +155,190 @@ func TestTryFunc(t *testing.T) {
     })
 }
 
+func TestTryCtx(t *testing.T) {
+    t.Run("cancel", func (t *testing.T) {
+        jh := New(context.Background())
+        ctx, cancel := context.WithCancel(context.Background())
+        j, ok := jh.TryCtx(ctx)
+        if !ok {
+            t.Fatal("unable to try")
+        }
+        jh.Stop()
+        cancel()
+        <-j.Context().Done()
+        if jh.WaitTimeout(10 * time.Millisecond) {
+            t.Fatal("drained before the job was done")
+        }
+        j.Done()
+        jh.WaitAll()
+        if n := jh.Stats().Expired; n != 0 {
+            t.Fatal("unexpected expired count", n)
+        }
+    })
+    t.Run("deadline", func (t *testing.T) {
+        jh := New(context.Background())
+        ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Millisecond)
+        defer cancel()
+        j, ok := jh.TryCtx(ctx)
+        if !ok {
+            t.Fatal("unable to try")
+        }
+        <-j.Context().Done()
+        j.Done()
+        jh.Stop()
+        jh.WaitAll()
+        if n := jh.Stats().Expired; n != 1 {
+            t.Fatal("unexpected expired count", n)
+        }
+    })
+    t.Run("background", func (t *testing.T) {
+        jh := New(context.Background())
+        j, ok := jh.TryCtx(context.Background())
+        if !ok {
+            t.Fatal("unable to try")
+        }
+        j.Done()
+        jh.Stop()
+        jh.WaitAll()
+    })
+    t.Run("done context", func (t *testing.T) {
+        jh := New(context.Background())
+        ctx, cancel := context.WithCancel(context.Background())
+        cancel()
+        if _, ok := jh.TryCtx(ctx); ok {
+            t.Fatal("should not accept jobs with done context")
+        }
+        jh.Stop()
+        jh.WaitAll()
+    })
+    t.Run("closed jobhandler", func (t *testing.T) {
+        jh := New(context.Background())
+        jh.Stop()
+        ctx, cancel := context.WithCancel(context.Background())
+        defer cancel()
+        if _, ok := jh.TryCtx(ctx); ok {
+            t.Fatal("should not accept jobs")
+        }
+        jh.WaitAll()
+    })
+}
+
+func TestTryFuncCtx(t *testing.T) {
+    t.Run("deadline", func (t *testing.T) {
+        jh := New(context.Background())
+        ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Millisecond)
+        defer cancel()
+        didRunFn := false
+        if !jh.TryFuncCtx(ctx, func (ctx context.Context) {
+            <-ctx.Done()
+            didRunFn = true
+        }) {
+            t.Fatal("unable to try")
+        }
+        if !didRunFn {
+            t.Fatal("function did not run")
+        }
+        if n := jh.Stats().Expired; n != 1 {
+            t.Fatal("unexpected expired count", n)
+        }
+        jh.Stop()
+        jh.WaitAll()
+    })
+    t.Run("stop", func (t *testing.T) {
+        jh := New(context.Background())
+        if !jh.TryFuncCtx(context.Background(), func (ctx context.Context) {
+            go jh.Stop()
+            <-ctx.Done()
+        }) {
+            t.Fatal("unable to try")
+        }
+        jh.WaitAll()
+        if n := jh.Stats().Expired; n != 0 {
+            t.Fatal("unexpected expired count", n)
+        }
+    })
+    t.Run("closed jobhandler", func (t *testing.T) {
+        jh := New(context.Background())
+        jh.Stop()
+        if jh.TryFuncCtx(context.Background(), func (ctx context.Context) {}) {
+            t.Fatal("should not accept jobs")
+        }
+        jh.WaitAll()
+    })
+}
+
+func TestTryFuncTimeout(t *testing.T) {
+    jh := New(context.Background())
+    if !jh.TryFuncTimeout(time.Millisecond, func(ctx context.Context) {
+        <-ctx.Done()
+    }) {
+        t.Fatal("unable to try")
+    }
+    if n := jh.Stats().Expired; n != 1 {
+        t.Fatal("expected 1 expired job, got", n)
+    }
+    done := make(chan struct{})
+    go func() {
+        jh.TryFuncTimeout(time.Hour, func(ctx context.Context) {
+            <-ctx.Done()
+        })
+        close(done)
+    }()
+    jh.Stop()
+    jh.WaitAll()
+    <-done
+    if n := jh.Stats().Expired; n != 1 {
+        t.Fatal("expected job cancelled by stop not to expire, got", n)
+    }
+}
+
+func TestTryFuncCtxAsync(t *testing.T) {
+    jh := New(context.Background())
+    var nCancelled atomic.Int32
+    wait := func (ctx context.Context) {
+        <-ctx.Done()
+        nCancelled.Add(1)
+    }
+    done := jh.TryFuncCtxAsync(context.Background(), wait)
+    if !<-jh.TryNFuncCtxAsync(context.Background(), 3, 0, func (ctx context.Context, i int) { wait(ctx) }) {
+        t.Fatal("unable to try")
+    }
+    jh.Stop()
+    if !<-done {
+        t.Fatal("unable to try")
+    }
+    jh.WaitAll()
+    if n := nCancelled.Load(); n != 4 {
+        t.Fatal("unexpected cancel count", n)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    if <-New(context.Background()).TryFuncCtxAsync(ctx, wait) {
+        t.Fatal("should not accept jobs with done context")
+    }
+}
+
+func TestTryNFuncCtxAsyncRelease(t *testing.T) {
+    jh := New(context.Background(), WithPanicHandler(func(any) {}))
+    ctxs := make(chan context.Context, 2)
+    <-jh.TryNFuncCtxAsync(context.Background(), 2, 2, func (ctx context.Context, i int) {
+        ctxs <- ctx
+        panic("job failed")
+    })
+    for i := 0; i < 2; i++ {
+        select {
+        case <-(<-ctxs).Done():
+        case <-time.After(time.Second):
+            t.Fatal("context not released after panics")
+        }
+    }
+    if jh.Stopped() {
+        t.Fatal("context released by stop")
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
 func TestTryFuncAsync(t *testing.T) {
     t.Run("open jobhandler", func (t *testing.T) {
         didRunFn := false
@@ -234,6 +432,66 @@ func TestTryNFuncAsync(t *testing.T) {
     })
 }
 
+func TestTryNFuncAsyncWorkers(t *testing.T) {
+    delta := 10000
+    limit := 4
+    calls := make([]atomic.Int32, delta)
+    var nRunning, maxRunning atomic.Int32
+    jh := New(context.Background())
+    if !<-jh.TryNFuncAsync(delta, limit, func (i int) {
+        n := nRunning.Add(1)
+        for {
+            m := maxRunning.Load()
+            if n <= m || maxRunning.CompareAndSwap(m, n) {
+                break
+            }
+        }
+        calls[i].Add(1)
+        nRunning.Add(-1)
+    }) {
+        t.Fatal("unable to try")
+    }
+    jh.Stop()
+    jh.WaitAll()
+    if m := maxRunning.Load(); m > int32(limit) {
+        t.Fatal("limit exceeded", m)
+    }
+    for i := range calls {
+        if n := calls[i].Load(); n != 1 {
+            t.Fatal("unexpected call count", i, n)
+        }
+    }
+}
+
+func TestSkipOnStop(t *testing.T) {
+    delta := 10
+    started := make(chan struct{})
+    release := make(chan struct{})
+    reported := make(chan int, 1)
+    var nRun atomic.Int32
+    jh := New(context.Background())
+    if !<-jh.TryNFuncAsync(delta, 1, func (i int) {
+        nRun.Add(1)
+        started <- struct{}{}
+        <-release
+    }, SkipOnStop(func (n int) { reported <- n })) {
+        t.Fatal("unable to try")
+    }
+    <-started
+    jh.Stop()
+    close(release)
+    if n := <-reported; n != delta - 1 {
+        t.Fatal("unexpected reported skip count", n)
+    }
+    jh.WaitAll()
+    if n := nRun.Load(); n != 1 {
+        t.Fatal("unexpected run count", n)
+    }
+    if n := jh.Stats().Skipped; n != uint64(delta - 1) {
+        t.Fatal("unexpected skip count", n)
+    }
+}
+
 func TestNegativeJobs(t *testing.T) {
     jh := New(context.Background())
     if !jh.TryN(0) {
@@ -271,4 +529,592 @@ func TestJobPanic(t *testing.T) {
         }()
         jh.Done()
     })
+    t.Run("named", func (t *testing.T) {
+        jh := New(context.Background(), WithName("server"))
+        jh.Stop()
+        defer func() {
+            if r := recover(); r == nil {
+                t.Fatal("should panic")
+            } else if r.(string) != "jobhandler server: negative job count" {
+                t.Fatal("unexpected panic", r)
+            }
+        }()
+        jh.Done()
+    })
+}
+
+func TestNewChild(t *testing.T) {
+    t.Run("names", func (t *testing.T) {
+        jh := New(context.Background(), WithName("server"))
+        child := jh.NewChild("ingest").NewChild("tenant42")
+        if child.Name() != "server.ingest.tenant42" {
+            t.Fatal("unexpected name", child.Name())
+        }
+        if New(context.Background()).NewChild("ingest").Name() != "ingest" {
+            t.Fatal("unexpected name of child of unnamed jobhandler")
+        }
+        jh.Stop()
+        jh.WaitAll()
+        if !child.Stopped() {
+            t.Fatal("child should be stopped")
+        }
+    })
+    t.Run("parent waits", func (t *testing.T) {
+        jh := New(context.Background())
+        child := jh.NewChild("child")
+        if !child.Try() {
+            t.Fatal("unable to try")
+        }
+        var childDone atomic.Bool
+        go func () {
+            time.Sleep(10 * time.Millisecond)
+            childDone.Store(true)
+            child.Done()
+        }()
+        jh.Stop()
+        jh.WaitAll()
+        if !childDone.Load() {
+            t.Fatal("parent did not wait for child")
+        }
+    })
+    t.Run("closed jobhandler", func (t *testing.T) {
+        jh := New(context.Background())
+        jh.Stop()
+        child := jh.NewChild("child")
+        if !child.Stopped() {
+            t.Fatal("child should be stopped")
+        }
+        jh.WaitAll()
+        child.WaitAll()
+    })
+}
+
+func TestStopCause(t *testing.T) {
+    t.Run("stop", func (t *testing.T) {
+        jh := New(context.Background())
+        if err := jh.StopCause(); err != nil {
+            t.Fatal("unexpected cause", err)
+        }
+        jh.Stop()
+        if err := jh.StopCause(); err != ErrStopped {
+            t.Fatal("unexpected cause", err)
+        }
+        jh.WaitAll()
+    })
+    t.Run("context cause", func (t *testing.T) {
+        errCause := errors.New("sigterm")
+        ctx, cancel := context.WithCancelCause(context.Background())
+        jh := New(ctx)
+        var jobCause error
+        if !jh.TryFuncCtx(context.Background(), func (ctx context.Context) {
+            cancel(errCause)
+            <-ctx.Done()
+            jobCause = context.Cause(ctx)
+        }) {
+            t.Fatal("unable to try")
+        }
+        jh.WaitAll()
+        if jobCause != errCause {
+            t.Fatal("unexpected job cause", jobCause)
+        }
+        if err := jh.StopCause(); err != errCause {
+            t.Fatal("unexpected cause", err)
+        }
+    })
+    t.Run("stop with error", func (t *testing.T) {
+        errFatal := errors.New("fatal")
+        jh := New(context.Background())
+        if err := jh.Err(); err != nil {
+            t.Fatal("unexpected error", err)
+        }
+        if !jh.StopWithError(errFatal) {
+            t.Fatal("unable to stop")
+        }
+        if jh.StopWithError(errors.New("other")) {
+            t.Fatal("should already be stopped")
+        }
+        <-jh.OnStop()
+        if err := jh.Err(); err != errFatal {
+            t.Fatal("unexpected error", err)
+        }
+        jh.WaitAll()
+    })
+    t.Run("zero jobhandler", func (t *testing.T) {
+        var jh JobHandler
+        if err := jh.StopCause(); err != ErrStopped {
+            t.Fatal("unexpected cause", err)
+        }
+    })
+}
+
+func TestWithLockedThread(t *testing.T) {
+    jh := New(context.Background())
+    var nRun atomic.Int32
+    jh.TryFunc(func () { nRun.Add(1) }, WithLockedThread())
+    <-jh.TryFuncAsync(func () { nRun.Add(1) }, WithLockedThread())
+    <-jh.TryNFuncAsync(8, 2, func (i int) { nRun.Add(1) }, WithLockedThread())
+    jh.Stop()
+    jh.WaitAll()
+    if n := nRun.Load(); n != 10 {
+        t.Fatal("unexpected run count", n)
+    }
+}
+
+func TestWithOrderSeed(t *testing.T) {
+    order := func (seed int64) []int {
+        jh := New(context.Background())
+        var arr []int
+        <-jh.TryNFuncAsync(20, 1, func (i int) { arr = append(arr, i) }, WithOrderSeed(seed))
+        jh.Stop()
+        jh.WaitAll()
+        return arr
+    }
+    a, b := order(42), order(42)
+    if !slices.Equal(a, b) {
+        t.Fatal("same seed gave different orders", a, b)
+    }
+    if slices.IsSorted(a) {
+        t.Fatal("order is not shuffled", a)
+    }
+    slices.Sort(a)
+    for i := range a {
+        if a[i] != i {
+            t.Fatal("unexpected value", a[i])
+        }
+    }
+    // A random seed is logged so the run can be replayed
+    var sb strings.Builder
+    jh := New(context.Background(), WithLogger(slog.New(slog.NewTextHandler(&sb, nil))))
+    var random []int
+    <-jh.TryNFuncAsync(20, 1, func (i int) { random = append(random, i) }, WithOrderSeed(0))
+    jh.Stop()
+    jh.WaitAll()
+    _, logged, ok := strings.Cut(sb.String(), `msg="jobhandler: order seed" seed=`)
+    if !ok {
+        t.Fatalf("seed not logged:\n%s", sb.String())
+    }
+    seed, err := strconv.ParseInt(strings.Fields(logged)[0], 10, 64)
+    if err != nil || seed == 0 {
+        t.Fatal("unexpected seed", logged, err)
+    }
+    if replay := order(seed); !slices.Equal(random, replay) {
+        t.Fatal("logged seed gave a different order", random, replay)
+    }
+}
+
+func TestYield(t *testing.T) {
+    jh := New(context.Background())
+    if err := jh.Yield(nil); err != nil {
+        t.Fatal("unexpected error", err)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    if err := jh.Yield(ctx); err != context.Canceled {
+        t.Fatal("unexpected error", err)
+    }
+    jh.Stop()
+    if err := jh.Yield(context.Background()); err != ErrStopped {
+        t.Fatal("unexpected error", err)
+    }
+    jh.WaitAll()
+}
+
+func TestHandlerContext(t *testing.T) {
+    jh := New(context.Background())
+    ctx := jh.Context()
+    if ctx.Err() != nil {
+        t.Fatal("context should not be cancelled")
+    }
+    jh.Stop()
+    <-ctx.Done()
+    if err := context.Cause(ctx); err != ErrStopped {
+        t.Fatal("unexpected cause", err)
+    }
+    jh.WaitAll()
+    var zero JobHandler
+    if zero.Context().Err() == nil {
+        t.Fatal("zero jobhandler context should be cancelled")
+    }
+}
+
+func TestWithMaxTotalJobs(t *testing.T) {
+    jh := New(context.Background(), WithMaxTotalJobs(3))
+    if !jh.TryN(2) {
+        t.Fatal("failed to take jobs")
+    }
+    if jh.TryN(2) {
+        t.Fatal("took jobs beyond the bound")
+    }
+    if jh.Stopped() {
+        t.Fatal("stopped before the bound was reached")
+    }
+    if !jh.Try() {
+        t.Fatal("failed to take the last job")
+    }
+    if !jh.Stopped() || jh.StopCause() != ErrMaxTotalJobs {
+        t.Fatal("not stopped after the bound was reached", jh.StopCause())
+    }
+    if jh.Try() {
+        t.Fatal("took a job after the bound was reached")
+    }
+    jh.Done()
+    jh.Done()
+    jh.Done()
+    jh.WaitAll()
+}
+
+func TestTrySleepCtx(t *testing.T) {
+    jh := New(context.Background())
+    if !jh.TrySleepCtx(context.Background(), time.Millisecond) {
+        t.Fatal("sleep was cancelled")
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    if jh.TrySleepCtx(ctx, time.Hour) {
+        t.Fatal("sleep was not cancelled by ctx")
+    }
+    jh.Stop()
+    if jh.TrySleepCtx(context.Background(), time.Hour) {
+        t.Fatal("sleep was not cancelled by stop")
+    }
+}
+
+func TestTrySplit(t *testing.T) {
+    jh := New(context.Background())
+    for _, total := range []int{0, -1} {
+        if n := jh.TrySplit(total, 2, func([]int) { t.Fatal("ran an empty split") }); n != 0 {
+            t.Fatal("unexpected jobs run", total, n)
+        }
+    }
+    if jh.Running() != 0 {
+        t.Fatal("empty split took jobs", jh.Running())
+    }
+    var batches [][]int
+    n := jh.TrySplit(5, 2, func(batch []int) {
+        batches = append(batches, append([]int(nil), batch...))
+        if len(batches) == 2 {
+            jh.Stop()
+        }
+    })
+    if n != 4 || len(batches) != 2 || batches[1][0] != 2 || batches[1][1] != 3 {
+        t.Fatal("unexpected batches", n, batches)
+    }
+    jh.WaitAll()
+    if n := jh.TrySplit(5, 2, func([]int) {}); n != 0 {
+        t.Fatal("ran jobs after stop", n)
+    }
+}
+
+func TestCounters(t *testing.T) {
+    jh := New(context.Background())
+    jh.TryN(3)
+    jh.Done()
+    jh.Stop()
+    jh.Try()
+    jh.TryN(2)
+    if r, a, rj := jh.Running(), jh.Accepted(), jh.Rejected(); r != 2 || a != 3 || rj != 2 {
+        t.Fatal("unexpected counters", r, a, rj)
+    }
+    jh.Done()
+    jh.Done()
+    jh.WaitAll()
+    if r := jh.Running(); r != 0 {
+        t.Fatal("unexpected running jobs", r)
+    }
+}
+
+func TestTryCritical(t *testing.T) {
+    jh := New(context.Background(), WithCriticalJobs(1))
+    if !jh.TryCritical() {
+        t.Fatal("failed to take a critical job while running")
+    }
+    jh.Stop()
+    if jh.Try() {
+        t.Fatal("took a job after stop")
+    }
+    if !jh.TryCritical() {
+        t.Fatal("failed to take a critical job while draining")
+    }
+    if jh.TryCritical() {
+        t.Fatal("took more critical jobs than allowed")
+    }
+    jh.Done()
+    jh.Done()
+    jh.WaitAll()
+    if jh.TryCritical() {
+        t.Fatal("took a critical job after drain")
+    }
+    jh = New(context.Background(), WithCriticalJobs(1))
+    jh.Stop()
+    jh.WaitAll()
+    // A Try racing the drain raises the count of the drained jobhandler
+    if jh.Try() {
+        t.Fatal("took a job after drain")
+    }
+    atomic.AddInt64(&jh.n, 1)
+    if jh.TryCritical() {
+        t.Fatal("took a critical job while the count of the drained jobhandler was raised")
+    }
+    atomic.AddInt64(&jh.n, -1)
+}
+
+func TestReset(t *testing.T) {
+    jh := New(context.Background(), WithCriticalJobs(1))
+    jh.Try()
+    jh.Stop()
+    jh.TryCritical()
+    jh.Done()
+    jh.Done()
+    jh.WaitAll()
+    jh.Reset()
+    if jh.Stopped() || jh.State() != Running || jh.StopCause() != nil {
+        t.Fatal("not running after reset")
+    }
+    if !jh.Try() {
+        t.Fatal("failed to take a job after reset")
+    }
+    jh.Stop()
+    if !jh.TryCritical() {
+        t.Fatal("critical jobs not re-armed")
+    }
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Fatal("reset before WaitAll did not panic")
+            }
+        }()
+        jh.Reset()
+    }()
+    jh.Done()
+    jh.Done()
+    jh.WaitAll()
+    if a := jh.Accepted(); a != 4 {
+        t.Fatal("unexpected accepted jobs", a)
+    }
+}
+
+func TestResetAfterForceStop(t *testing.T) {
+    jh := New(context.Background())
+    jh.Try()
+    jh.ForceStop()
+    jh.WaitAll()
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Fatal("reset after ForceStop did not panic")
+            }
+        }()
+        jh.Reset()
+    }()
+    jh.Done()
+    if n := jh.Stats().LateDone; n != 1 {
+        t.Fatal("expected late Done to be counted, got", n)
+    }
+}
+
+func TestResetRemovesMarker(t *testing.T) {
+    marker := FileMarker(t.TempDir() + "/clean")
+    jh := New(context.Background(), WithShutdownMarker(marker))
+    jh.Stop()
+    jh.WaitAll()
+    if clean, _ := marker.Load(); !clean {
+        t.Fatal("marker not stored after clean drain")
+    }
+    jh.Reset()
+    if clean, _ := marker.Load(); clean {
+        t.Fatal("marker not removed by reset")
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func TestWithMaxConcurrent(t *testing.T) {
+    jh := New(context.Background(), WithMaxConcurrent(2), WithMaxTotalJobs(3))
+    if !jh.TryN(2) {
+        t.Fatal("failed to take jobs within bound")
+    }
+    if jh.Try() {
+        t.Fatal("took a job beyond bound")
+    }
+    jh.Done()
+    if !jh.Try() {
+        t.Fatal("failed to take a job after another was done")
+    }
+    if !jh.Stopped() {
+        t.Fatal("rejected job counted towards max total jobs")
+    }
+    jh.Done()
+    jh.Done()
+    jh.WaitAll()
+}
+
+func TestAlsoStopOn(t *testing.T) {
+    jh := New(context.Background())
+    errKill := errors.New("kill switch")
+    ctx, cancel := context.WithCancelCause(context.Background())
+    jh.AlsoStopOn(ctx)
+    cancel(errKill)
+    jh.WaitAll()
+    if jh.StopCause() != errKill {
+        t.Fatal("unexpected cause", jh.StopCause())
+    }
+}
+
+func TestAcquire(t *testing.T) {
+    jh := New(context.Background(), WithMaxConcurrent(1))
+    if !jh.Acquire(context.Background()) {
+        t.Fatal("failed to acquire within bound")
+    }
+    acquired := make(chan bool)
+    go func () {
+        acquired <- jh.Acquire(context.Background())
+    }()
+    select {
+    case <-acquired:
+        t.Fatal("acquired beyond bound")
+    case <-time.After(10 * time.Millisecond):
+    }
+    jh.Done()
+    if !<-acquired {
+        t.Fatal("failed to acquire freed capacity")
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+    defer cancel()
+    if jh.Acquire(ctx) {
+        t.Fatal("acquired beyond bound")
+    }
+    go jh.Stop()
+    if jh.Acquire(context.Background()) {
+        t.Fatal("acquired after stop")
+    }
+    jh.Done()
+    jh.WaitAll()
+}
+
+func TestAcquireBudget(t *testing.T) {
+    b := NewBudget(1)
+    a := New(context.Background(), WithBudget(b))
+    c := New(context.Background(), WithBudget(b))
+    a.Try()
+    acquired := make(chan bool)
+    go func () {
+        acquired <- c.Acquire(context.Background())
+    }()
+    a.Done()
+    if !<-acquired {
+        t.Fatal("failed to acquire budget freed by another jobhandler")
+    }
+    c.Done()
+}
+
+func TestWithPacing(t *testing.T) {
+    jh := New(context.Background())
+    var wg sync.WaitGroup
+    wg.Add(4)
+    start := time.Now()
+    <-jh.TryNFuncAsync(4, 4, func (i int) {
+        wg.Done()
+    }, WithPacing(40 * time.Millisecond))
+    wg.Wait()
+    jh.Stop()
+    jh.WaitAll()
+    if d := time.Since(start); d < 30 * time.Millisecond {
+        t.Fatal("jobs were not paced", d)
+    }
+    jh = New(context.Background())
+    <-jh.TryNFuncAsync(4, 1, func (i int) {
+        jh.Stop()
+    }, WithPacing(time.Hour), SkipOnStop(nil))
+    jh.WaitAll()
+    if skipped := jh.Stats().Skipped; skipped != 3 {
+        t.Fatal("unexpected number of skipped jobs", skipped)
+    }
+}
+
+func TestWithPacingStop(t *testing.T) {
+    jh := New(context.Background())
+    var ran atomic.Int64
+    <-jh.TryNFuncAsync(8, 8, func (i int) {
+        ran.Add(1)
+        jh.Stop()
+    }, WithPacing(time.Hour))
+    jh.WaitAll()
+    if n := ran.Load(); n != 1 {
+        t.Fatal("paced jobs started at once on stop", n)
+    }
+    if skipped := jh.Stats().Skipped; skipped != 7 {
+        t.Fatal("unexpected number of skipped jobs", skipped)
+    }
+    jh = New(context.Background())
+    var reported atomic.Int64
+    <-jh.TryNFuncAsync(8, 2, func (i int) {
+        jh.Stop()
+    }, WithPacing(time.Hour), SkipOnStop(func(n int) { reported.Add(int64(n)) }))
+    jh.WaitAll()
+    if n := reported.Load(); n != 7 {
+        t.Fatal("unexpected number of reported skips", n)
+    }
+}
+
+func TestTryAllocs(t *testing.T) {
+    jh := New(context.Background())
+    allocs := testing.AllocsPerRun(100, func() {
+        if jh.Stopped() || !jh.Try() {
+            t.Fatal("rejected job while running")
+        }
+        jh.Done()
+    })
+    if allocs != 0 {
+        t.Fatal("expected Stopped, Try and Done not to allocate, got", allocs)
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func BenchmarkTryParallel(b *testing.B) {
+    jh := New(context.Background())
+    b.ReportAllocs()
+    b.RunParallel(func(pb *testing.PB) {
+        for pb.Next() {
+            if jh.Try() {
+                jh.Done()
+            }
+        }
+    })
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func BenchmarkTryFuncAsync(b *testing.B) {
+    jh := New(context.Background())
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        <-jh.TryFuncAsync(func() {})
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func TestTryRacingStop(t *testing.T) {
+    for i := 0; i < 1000; i++ {
+        jh := New(context.Background())
+        var wg sync.WaitGroup
+        for j := 0; j < 4; j++ {
+            wg.Add(1)
+            go func() {
+                defer wg.Done()
+                for k := 0; k < 10; k++ {
+                    if jh.Try() {
+                        jh.Done()
+                    }
+                }
+            }()
+        }
+        jh.Stop()
+        wg.Wait()
+        jh.WaitAll()
+        if jh.Try() {
+            t.Fatal("took a job after drain")
+        }
+    }
 }
@@ -0,0 +1,97 @@
+// Package jobhandlertest provides utilities for testing code built on jobhandler.
+package jobhandlertest
+
+import(
+    "github.com/cblach/jobhandler"
+    "sync"
+    "time"
+)
+
+// A FakeClock is a jobhandler.Clock that only advances when told to,
+// so time dependent logic can be tested deterministically without sleeping.
+type FakeClock struct {
+    mu     sync.Mutex
+    cond   sync.Cond
+    now    time.Time
+    timers map[*fakeTimer]struct{}
+}
+
+var _ jobhandler.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+    c := &FakeClock{
+        now:    now,
+        timers: make(map[*fakeTimer]struct{}),
+    }
+    c.cond.L = &c.mu
+    return c
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.now
+}
+
+// NewTimer creates a timer that fires once the clock is advanced by d.
+func (c *FakeClock) NewTimer(d time.Duration) jobhandler.Timer {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    t := &fakeTimer{
+        c:        c,
+        ch:       make(chan time.Time, 1),
+        deadline: c.now.Add(d),
+    }
+    if d <= 0 {
+        t.ch <- c.now
+        return t
+    }
+    c.timers[t] = struct{}{}
+    c.cond.Broadcast()
+    return t
+}
+
+// Advance moves the clock forward by d and fires all timers that are due.
+func (c *FakeClock) Advance(d time.Duration) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.now = c.now.Add(d)
+    for t := range c.timers {
+        if !t.deadline.After(c.now) {
+            delete(c.timers, t)
+            t.ch <- c.now
+        }
+    }
+    c.cond.Broadcast()
+}
+
+// BlockUntilTimers blocks until at least n timers are waiting to fire.
+// Use it to make sure the code under test is waiting before calling Advance.
+func (c *FakeClock) BlockUntilTimers(n int) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for len(c.timers) < n {
+        c.cond.Wait()
+    }
+}
+
+type fakeTimer struct {
+    c        *FakeClock
+    ch       chan time.Time
+    deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+    return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+    t.c.mu.Lock()
+    defer t.c.mu.Unlock()
+    _, ok := t.c.timers[t]
+    delete(t.c.timers, t)
+    t.c.cond.Broadcast()
+    return ok
+}
@@ -0,0 +1,41 @@
+package jobhandlertest
+import(
+    "context"
+    "github.com/cblach/jobhandler"
+    "testing"
+    "time"
+)
+
+func TestFakeClock(t *testing.T) {
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    clk := NewFakeClock(start)
+    jh := jobhandler.New(context.Background(), jobhandler.WithClock(clk))
+    slept := make(chan bool)
+    go func () {
+        slept <- jh.TrySleep(time.Hour)
+    }()
+    clk.BlockUntilTimers(1)
+    clk.Advance(30 * time.Minute)
+    select {
+    case <-slept:
+        t.Fatal("slept too short")
+    default:
+    }
+    clk.Advance(30 * time.Minute)
+    if !<-slept {
+        t.Fatal("sleep should be done")
+    }
+    if now := clk.Now(); !now.Equal(start.Add(time.Hour)) {
+        t.Fatal("unexpected time", now)
+    }
+    go func () {
+        slept <- jh.TrySleep(time.Hour)
+    }()
+    clk.BlockUntilTimers(1)
+    jh.Stop()
+    if <-slept {
+        t.Fatal("sleep should be cancelled")
+    }
+    clk.BlockUntilTimers(0)
+    jh.WaitAll()
+}
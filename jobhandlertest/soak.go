@@ -0,0 +1,150 @@
+package jobhandlertest
+
+import(
+    "context"
+    "github.com/cblach/jobhandler"
+    "math/rand"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// SoakConfig configures Soak.
+type SoakConfig struct {
+    // New creates the jobhandler under test.
+    // The default is jobhandler.New(context.Background()).
+    New func() *jobhandler.JobHandler
+    // Goroutines is the number of goroutines hammering the jobhandler.
+    // The default is 8.
+    Goroutines int
+    // Ops is the number of random operations per goroutine.
+    // The default is 1000.
+    Ops int
+    // Seed seeds the random operation sequences.
+    Seed int64
+    // Timeout bounds how long WaitAll may block after the operations are done.
+    // The default is 10 seconds.
+    Timeout time.Duration
+}
+
+// Soak hammers a jobhandler with randomized sequences of Try, TryN, Done, Stop
+// and the Func helpers across goroutines and validates the lifecycle invariants:
+// every accepted job runs and is done exactly once, nothing is accepted after
+// Stop returns, and WaitAll returns once all jobs are done.
+// The same Seed reproduces the same operation sequences.
+func Soak(t testing.TB, cfg SoakConfig) {
+    t.Helper()
+    if cfg.New == nil {
+        cfg.New = func() *jobhandler.JobHandler {
+            return jobhandler.New(context.Background())
+        }
+    }
+    if cfg.Goroutines <= 0 {
+        cfg.Goroutines = 8
+    }
+    if cfg.Ops <= 0 {
+        cfg.Ops = 1000
+    }
+    if cfg.Timeout <= 0 {
+        cfg.Timeout = 10 * time.Second
+    }
+    jh := cfg.New()
+    var (
+        wg          sync.WaitGroup
+        accepted    atomic.Int64
+        ran         atomic.Int64
+        afterStop   atomic.Int64
+        stopped     atomic.Bool
+    )
+    // accept records n accepted jobs, and flags a violation if they were
+    // accepted after Stop returned.
+    accept := func(stoppedBefore bool, n int) {
+        accepted.Add(int64(n))
+        if stoppedBefore {
+            afterStop.Add(int64(n))
+        }
+    }
+    run := func() {
+        ran.Add(1)
+    }
+    for g := 0; g < cfg.Goroutines; g++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            rng := rand.New(rand.NewSource(cfg.Seed + int64(g)))
+            pending := 0
+            for op := 0; op < cfg.Ops; op++ {
+                s := stopped.Load()
+                switch rng.Intn(8) {
+                case 0:
+                    if jh.Try() {
+                        accept(s, 1)
+                        pending++
+                    }
+                case 1:
+                    n := rng.Intn(4)
+                    if jh.TryN(n) {
+                        accept(s, n)
+                        pending += n
+                    }
+                case 2, 3:
+                    if pending > 0 {
+                        run()
+                        jh.Done()
+                        pending--
+                    }
+                case 4:
+                    if jh.TryFunc(run) {
+                        accept(s, 1)
+                    }
+                case 5:
+                    if <-jh.TryFuncAsync(run) {
+                        accept(s, 1)
+                    }
+                case 6:
+                    n := rng.Intn(4)
+                    if <-jh.TryNFuncAsync(n, rng.Intn(3), func (int) { run() }) {
+                        accept(s, n)
+                    }
+                case 7:
+                    if rng.Intn(cfg.Ops / 10 + 1) == 0 {
+                        jh.Stop()
+                        stopped.Store(true)
+                    }
+                }
+            }
+            for ; pending > 0; pending-- {
+                run()
+                jh.Done()
+            }
+        }()
+    }
+    wg.Wait()
+    jh.Stop()
+    if !jh.Stopped() {
+        t.Fatal("jobhandler is not stopped after Stop")
+    }
+    if jh.Try() {
+        t.Fatal("stopped jobhandler accepted a job")
+    }
+    waited := make(chan struct{})
+    go func() {
+        jh.WaitAll()
+        close(waited)
+    }()
+    select {
+    case <-waited:
+    case <-time.After(cfg.Timeout):
+        t.Fatal("WaitAll did not return after all jobs were done")
+    }
+    if n := afterStop.Load(); n != 0 {
+        t.Fatal("jobs accepted after Stop returned:", n)
+    }
+    if a, r := accepted.Load(), ran.Load(); a != r {
+        t.Fatalf("accepted %d jobs, but %d ran", a, r)
+    }
+    if s := jh.State(); s != jobhandler.Stopped {
+        t.Fatal("unexpected state after WaitAll", s)
+    }
+}
@@ -0,0 +1,8 @@
+package jobhandlertest
+import(
+    "testing"
+)
+
+func TestSoak(t *testing.T) {
+    Soak(t, SoakConfig{Seed: 1})
+}
@@ -0,0 +1,74 @@
+package jobhandler
+
+// WithKeyLimit bounds the number of jobs taken with TryKey that are not
+// done to n per key, e.g. per tenant or per host. Jobs that would exceed
+// n are rejected. A n <= 0 does not bound the jobs.
+func WithKeyLimit(n int) Option {
+    return func(jh *JobHandler) {
+        jh.keyLimit = n
+    }
+}
+
+// TryKey attempts to take on a single job of key like Try,
+// bounded per key by WithKeyLimit.
+// Returns true if job is successfully taken
+// and false if the JobHandler is stopped or key is at its limit.
+// When the job is done call DoneKey with the same key, not Done.
+func (jh *JobHandler) TryKey(key string) bool {
+    jh.mu.Lock()
+    if jh.keyLimit > 0 && jh.keys[key] >= jh.keyLimit {
+        jh.mu.Unlock()
+        return jh.reject("key limit")
+    }
+    if jh.keys == nil {
+        jh.keys = make(map[string]int)
+    }
+    jh.keys[key]++
+    jh.mu.Unlock()
+    if !jh.Try() {
+        jh.releaseKey(key)
+        return false
+    }
+    return true
+}
+
+// DoneKey must be called when a job taken with TryKey is done.
+func (jh *JobHandler) DoneKey(key string) {
+    jh.releaseKey(key)
+    jh.Done()
+}
+
+// TryFuncKey is like TryFunc, but takes the job with TryKey.
+// Do not call DoneKey, the job is automatically
+// flagged as done after fn exits.
+func (jh *JobHandler) TryFuncKey(key string, fn func(), opts ...JobOption) bool {
+    if !jh.TryKey(key) {
+        return false
+    }
+    cfg := newJobConfig(opts)
+    unlock := cfg.lock()
+    jh.runJob("", fn)
+    unlock()
+    jh.DoneKey(key)
+    return true
+}
+
+// KeyRunning returns the number of jobs of key that are not done.
+func (jh *JobHandler) KeyRunning(key string) int {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    return jh.keys[key]
+}
+
+// releaseKey releases the slot of a job of key.
+func (jh *JobHandler) releaseKey(key string) {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    if jh.keys[key] <= 0 {
+        jh.panic("DoneKey without TryKey for key " + key)
+    }
+    jh.keys[key]--
+    if jh.keys[key] == 0 {
+        delete(jh.keys, key)
+    }
+}
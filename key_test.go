@@ -0,0 +1,35 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+)
+
+func TestTryKey(t *testing.T) {
+    jh := New(context.Background(), WithKeyLimit(2))
+    if !jh.TryKey("tenant1") || !jh.TryKey("tenant1") {
+        t.Fatal("expected jobs of tenant1 to be taken")
+    }
+    if jh.TryKey("tenant1") {
+        t.Fatal("expected third job of tenant1 to be rejected")
+    }
+    if !jh.TryKey("tenant2") {
+        t.Fatal("expected job of tenant2 to be taken")
+    }
+    if n := jh.KeyRunning("tenant1"); n != 2 {
+        t.Fatal("expected 2 jobs of tenant1 running, got", n)
+    }
+    jh.DoneKey("tenant1")
+    if !jh.TryFuncKey("tenant1", func() {}) {
+        t.Fatal("expected job of tenant1 to be taken after DoneKey")
+    }
+    jh.Stop()
+    if jh.TryKey("tenant3") {
+        t.Fatal("expected job to be rejected after stop")
+    }
+    if n := jh.KeyRunning("tenant3"); n != 0 {
+        t.Fatal("expected rejected job to release its key, got", n)
+    }
+    jh.DoneKey("tenant1")
+    jh.DoneKey("tenant2")
+    jh.WaitAll()
+}
@@ -0,0 +1,98 @@
+package jobhandler
+
+import(
+    "context"
+    "runtime/pprof"
+    "runtime/trace"
+    "strconv"
+)
+
+// WithTraceLog makes the jobhandler trace the jobs run by the Func helpers
+// as runtime/trace tasks, logging events when they start and end, and
+// the drain as a task from stop until all jobs are done,
+// while tracing is enabled. go tool trace then shows the job boundaries
+// and the drain tail.
+func WithTraceLog() Option {
+    return func(jh *JobHandler) {
+        jh.traceLog = true
+    }
+}
+
+// WithPanicHandler makes the jobhandler recover panics of the jobs run by
+// the Func helpers and the Pool workers and pass them to fn, which is called
+// on the panicking goroutine, so runtime/debug.Stack reports the panic.
+// The job is flagged as done as if it returned.
+func WithPanicHandler(fn func(recovered any)) Option {
+    return func(jh *JobHandler) {
+        jh.panicHandler = fn
+    }
+}
+
+// runJob runs fn as a job of group, which may be empty.
+// If the jobhandler is named or group is set, fn runs with the pprof labels
+// "jobhandler" and "group", so profiles attribute the work to the job.
+func (jh *JobHandler) runJob(group string, fn func()) {
+    if jh.panicHandler != nil {
+        defer jh.recoverPanic()
+    }
+    if jh.name == "" && group == "" && !jh.traceLog {
+        fn()
+        return
+    }
+    var labels []string
+    if jh.name != "" {
+        labels = append(labels, "jobhandler", jh.name)
+    }
+    if group != "" {
+        labels = append(labels, "group", group)
+    }
+    pprof.Do(context.Background(), pprof.Labels(labels...), func(ctx context.Context) {
+        if jh.traceLog && trace.IsEnabled() {
+            var task *trace.Task
+            ctx, task = trace.NewTask(ctx, "jobhandler job " + group)
+            defer task.End()
+            trace.Log(ctx, "jobhandler", "job start " + group)
+            defer trace.Log(ctx, "jobhandler", "job end " + group)
+        }
+        fn()
+    })
+}
+
+// runIndex runs fn(i) like runJob runs a job of a running worker.
+// If the jobhandler is named, fn runs with the pprof labels
+// "jobhandler" and "index", so profiles attribute the work to the index.
+func (jh *JobHandler) runIndex(fn func(int), i int) {
+    if jh.panicHandler != nil {
+        defer jh.recoverPanic()
+    }
+    if jh.name == "" {
+        fn(i)
+        return
+    }
+    labels := pprof.Labels("jobhandler", jh.name, "index", strconv.Itoa(i))
+    pprof.Do(context.Background(), labels, func(context.Context) {
+        fn(i)
+    })
+}
+
+// recoverPanic recovers a panic and passes it to the panic handler.
+// It must be deferred directly.
+func (jh *JobHandler) recoverPanic() {
+    if r := recover(); r != nil {
+        jh.panicHandler(r)
+    }
+}
+
+// traceDrain starts the drain task of the jobhandler, if traced.
+func (jh *JobHandler) traceDrain() {
+    if jh.traceLog && trace.IsEnabled() {
+        _, jh.drainTask = trace.NewTask(context.Background(), "jobhandler drain " + jh.name)
+    }
+}
+
+// endTraceDrain ends the drain task of the jobhandler, if any.
+func (jh *JobHandler) endTraceDrain() {
+    if jh.drainTask != nil {
+        jh.drainTask.End()
+    }
+}
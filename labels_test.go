@@ -0,0 +1,77 @@
+package jobhandler
+import(
+    "bytes"
+    "context"
+    "runtime/pprof"
+    "runtime/trace"
+    "strings"
+    "sync/atomic"
+    "testing"
+)
+
+func TestJobLabels(t *testing.T) {
+    jh := New(context.Background(), WithName("server"))
+    var sb strings.Builder
+    jh.TryFuncGroup("reindex", func (ctx context.Context) {
+        pprof.Lookup("goroutine").WriteTo(&sb, 1)
+    })
+    if !strings.Contains(sb.String(), `"group":"reindex"`) || !strings.Contains(sb.String(), `"jobhandler":"server"`) {
+        t.Fatal("missing labels in goroutine profile")
+    }
+    var idx strings.Builder
+    <-jh.TryNFuncAsync(4, 1, func (i int) {
+        if i == 3 {
+            pprof.Lookup("goroutine").WriteTo(&idx, 1)
+        }
+    })
+    jh.Stop()
+    jh.WaitAll()
+    if !strings.Contains(idx.String(), `"index":"3"`) {
+        t.Fatal("missing index label in goroutine profile")
+    }
+}
+
+func TestWithPanicHandler(t *testing.T) {
+    var recovered atomic.Int64
+    jh := New(context.Background(), WithPanicHandler(func(r any) {
+        if r != "boom" {
+            t.Error("unexpected panic", r)
+        }
+        recovered.Add(1)
+    }))
+    <-jh.TryFuncAsync(func() { panic("boom") })
+    <-jh.TryNFuncAsync(4, 2, func(i int) {
+        if i%2 == 0 {
+            panic("boom")
+        }
+    })
+    p := NewPool(jh, 1, func(ctx context.Context, fail bool) {
+        if fail {
+            panic("boom")
+        }
+    })
+    p.Submit(true)
+    p.Submit(false)
+    jh.Stop()
+    jh.WaitAll()
+    if n := recovered.Load(); n != 4 {
+        t.Fatal("unexpected number of recovered panics", n)
+    }
+}
+
+func TestWithTraceLog(t *testing.T) {
+    var buf bytes.Buffer
+    if err := trace.Start(&buf); err != nil {
+        t.Skip("tracing is unavailable", err)
+    }
+    jh := New(context.Background(), WithTraceLog(), WithName("server"))
+    jh.TryFuncGroup("reindex", func (ctx context.Context) {})
+    jh.Stop()
+    jh.WaitAll()
+    trace.Stop()
+    for _, s := range []string{"jobhandler job reindex", "jobhandler drain server"} {
+        if !bytes.Contains(buf.Bytes(), []byte(s)) {
+            t.Fatalf("trace does not contain task %q", s)
+        }
+    }
+}
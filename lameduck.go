@@ -0,0 +1,74 @@
+package jobhandler
+
+import(
+    "time"
+)
+
+// Drain announces the shutdown of the jobhandler, closing the channel
+// returned by OnStop and flipping Ready to false, but keeps taking on jobs
+// for the lame duck period, e.g. while a load balancer deregisters the
+// process. Then the jobhandler is stopped like by Stop.
+// Drain blocks until the jobhandler is stopped, which may happen
+// before the lame duck period ends.
+func (jh *JobHandler) Drain(lameDuck time.Duration) {
+    if jh.Stopped() {
+        return
+    }
+    jh.announce()
+    t := jh.clock().NewTimer(lameDuck)
+    defer t.Stop()
+    select {
+    case <-t.C():
+        jh.stop(ErrStopped)
+    case <-jh.stopChan:
+    }
+}
+
+// WithPreStopDelay makes Stop, StopWithError, RequestStop, the context
+// passed to New, the signals of WithSignals and reaching WithMaxTotalJobs
+// announce the shutdown like Drain, flipping Ready to false, and keep
+// taking on jobs for d before the jobhandler is stopped, e.g. while
+// Kubernetes propagates the removal of the endpoint.
+// Stop, StopWithError and RequestStop block during the delay.
+func WithPreStopDelay(d time.Duration) Option {
+    return func(jh *JobHandler) {
+        jh.preStopDelay = d
+    }
+}
+
+// stopAfterDelay stops the jobhandler with the stop cause cause
+// after the pre-stop delay, see WithPreStopDelay.
+// Returns true if this call stopped the jobhandler.
+func (jh *JobHandler) stopAfterDelay(cause error) bool {
+    if jh.preStopDelay <= 0 || jh.Stopped() {
+        return jh.stop(cause)
+    }
+    if !jh.preStopping.CompareAndSwap(false, true) {
+        // Another stop is delayed already
+        <-jh.stopChan
+        return false
+    }
+    jh.announce()
+    t := jh.clock().NewTimer(jh.preStopDelay)
+    defer t.Stop()
+    select {
+    case <-t.C():
+        return jh.stop(cause)
+    case <-jh.stopChan:
+        return false
+    }
+}
+
+// Ready returns true if the jobhandler is running, has not
+// announced its shutdown, see Drain, and is not paused by a failing
+// probe, see WithProbe. Use it for readiness probes.
+func (jh *JobHandler) Ready() bool {
+    return !jh.Stopped() && !jh.announced.Load() && !jh.paused.Load()
+}
+
+// announce closes the channel returned by OnStop, if not already closed.
+func (jh *JobHandler) announce() {
+    if jh.announced.CompareAndSwap(false, true) {
+        close(jh.announceChan)
+    }
+}
@@ -0,0 +1,90 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+func TestDrain(t *testing.T) {
+    jh := New(context.Background())
+    if !jh.Ready() {
+        t.Fatal("not ready while running")
+    }
+    done := make(chan struct{})
+    go func () {
+        jh.Drain(time.Hour)
+        close(done)
+    }()
+    <-jh.OnStop()
+    if jh.Ready() {
+        t.Fatal("ready after shutdown was announced")
+    }
+    if !jh.Try() {
+        t.Fatal("rejected a job during the lame duck period")
+    }
+    jh.Stop()
+    <-done
+    if jh.Try() {
+        t.Fatal("took a job after stop")
+    }
+    jh.Done()
+    jh.WaitAll()
+    var zero JobHandler
+    zero.Drain(time.Hour)
+    if zero.Ready() {
+        t.Fatal("zero jobhandler is ready")
+    }
+}
+
+func TestWithPreStopDelay(t *testing.T) {
+    jh := New(context.Background(), WithPreStopDelay(50 * time.Millisecond))
+    stopped := make(chan bool)
+    go func () {
+        stopped <- jh.Stop()
+    }()
+    <-jh.OnStop()
+    if jh.Ready() {
+        t.Fatal("ready during the pre-stop delay")
+    }
+    if !jh.Try() {
+        t.Fatal("rejected a job during the pre-stop delay")
+    }
+    if jh.Stop() {
+        t.Fatal("second Stop initiated the stop")
+    }
+    if !<-stopped {
+        t.Fatal("delayed Stop did not initiate the stop")
+    }
+    if jh.Try() {
+        t.Fatal("took a job after the pre-stop delay")
+    }
+    jh.Done()
+    jh.WaitAll()
+}
+
+func TestWithPreStopDelayStopPaths(t *testing.T) {
+    t.Run("RequestStop", func (t *testing.T) {
+        jh := New(context.Background(), WithPreStopDelay(50 * time.Millisecond))
+        go jh.RequestStop("rebalance")
+        <-jh.OnStop()
+        if jh.Ready() || jh.Stopped() {
+            t.Fatal("RequestStop skipped the pre-stop delay")
+        }
+        jh.WaitAll()
+    })
+    t.Run("WithMaxTotalJobs", func (t *testing.T) {
+        jh := New(context.Background(), WithPreStopDelay(50 * time.Millisecond), WithMaxTotalJobs(1))
+        if !jh.Try() {
+            t.Fatal("unable to try")
+        }
+        <-jh.OnStop()
+        if jh.Ready() || jh.Stopped() {
+            t.Fatal("WithMaxTotalJobs skipped the pre-stop delay")
+        }
+        jh.Done()
+        jh.WaitAll()
+        if jh.StopCause() != ErrMaxTotalJobs {
+            t.Fatal("unexpected stop cause", jh.StopCause())
+        }
+    })
+}
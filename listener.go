@@ -0,0 +1,158 @@
+package jobhandler
+
+import(
+    "net"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// A ListenerOption configures a listener created by WrapListener.
+type ListenerOption func(*listener)
+
+// WithIdleClose makes the listener close the connections that have not
+// read or written for d once the jobhandler is stopped, instead of waiting
+// for their peers to close them. The connections are checked every d/2
+// until the jobhandler is drained.
+func WithIdleClose(d time.Duration) ListenerOption {
+    return func(l *listener) {
+        l.idle = d
+    }
+}
+
+// WrapListener returns a listener that counts each connection accepted
+// from ln as a job of the jobhandler until the connection is closed.
+// When the jobhandler is stopped, ln is closed and Accept returns ErrStopped,
+// so that servers stop accepting while the open connections drain.
+// Connections rejected by the jobhandler while it is running, e.g. by
+// WithMaxConcurrent, are closed and Accept waits for the next connection.
+func (jh *JobHandler) WrapListener(ln net.Listener, opts ...ListenerOption) net.Listener {
+    l := &listener{
+        Listener: ln,
+        jh:       jh,
+        closed:   make(chan struct{}),
+        conns:    make(map[*conn]struct{}),
+    }
+    for _, opt := range opts {
+        opt(l)
+    }
+    stopChan := jh.stopCh()
+    go func() {
+        select {
+        case <-stopChan:
+            ln.Close()
+            if l.idle > 0 {
+                l.closeIdle(jh.Drained())
+            }
+        case <-l.closed:
+        }
+    }()
+    return l
+}
+
+type listener struct {
+    net.Listener
+    jh        *JobHandler
+    closeOnce sync.Once
+    closed    chan struct{}
+    idle      time.Duration
+    mu        sync.Mutex
+    conns     map[*conn]struct{}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+    for {
+        c, err := l.Listener.Accept()
+        if err != nil {
+            if l.jh.Stopped() {
+                return nil, ErrStopped
+            }
+            return nil, err
+        }
+        if !l.jh.Try() {
+            c.Close()
+            if l.jh.Stopped() {
+                return nil, ErrStopped
+            }
+            continue
+        }
+        tc := &conn{Conn: c, l: l}
+        tc.touch()
+        l.mu.Lock()
+        l.conns[tc] = struct{}{}
+        l.mu.Unlock()
+        return tc, nil
+    }
+}
+
+func (l *listener) Close() error {
+    l.closeOnce.Do(func() {
+        close(l.closed)
+    })
+    return l.Listener.Close()
+}
+
+// closeIdle closes the idle connections every idle/2 until drained is closed.
+func (l *listener) closeIdle(drained <-chan struct{}) {
+    for {
+        t := l.jh.clock().NewTimer(max(l.idle / 2, time.Millisecond))
+        select {
+        case <-t.C():
+        case <-drained:
+            t.Stop()
+            return
+        }
+        now := l.jh.clock().Now().UnixNano()
+        var idle []*conn
+        l.mu.Lock()
+        for c := range l.conns {
+            if now - c.active.Load() >= int64(l.idle) {
+                idle = append(idle, c)
+            }
+        }
+        l.mu.Unlock()
+        for _, c := range idle {
+            c.Close()
+        }
+    }
+}
+
+// conn is a connection accepted by a listener, which is done when closed.
+type conn struct {
+    net.Conn
+    l         *listener
+    active    atomic.Int64
+    closeOnce sync.Once
+}
+
+// touch records that the connection is active.
+func (c *conn) touch() {
+    c.active.Store(c.l.jh.clock().Now().UnixNano())
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+    n, err := c.Conn.Read(b)
+    if n > 0 {
+        c.touch()
+    }
+    return n, err
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+    n, err := c.Conn.Write(b)
+    if n > 0 {
+        c.touch()
+    }
+    return n, err
+}
+
+func (c *conn) Close() error {
+    err := c.Conn.Close()
+    c.closeOnce.Do(func() {
+        c.l.mu.Lock()
+        delete(c.l.conns, c)
+        c.l.mu.Unlock()
+        c.l.jh.Done()
+    })
+    return err
+}
@@ -0,0 +1,99 @@
+package jobhandler
+import(
+    "context"
+    "net"
+    "testing"
+    "time"
+)
+
+func TestWrapListener(t *testing.T) {
+    jh := New(context.Background())
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ln = jh.WrapListener(ln)
+    defer ln.Close()
+    client, err := net.Dial("tcp", ln.Addr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer client.Close()
+    c, err := ln.Accept()
+    if err != nil {
+        t.Fatal(err)
+    }
+    jh.Stop()
+    if _, err := ln.Accept(); err != ErrStopped {
+        t.Fatal("unexpected error", err)
+    }
+    if jh.State() != Draining {
+        t.Fatal("drained with an open connection")
+    }
+    c.Close()
+    c.Close()
+    jh.WaitAll()
+}
+
+func TestWrapListenerRejects(t *testing.T) {
+    jh := New(context.Background(), WithMaxConcurrent(1))
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ln = jh.WrapListener(ln)
+    defer ln.Close()
+    jh.Try()
+    accepted := make(chan error, 1)
+    go func() {
+        c, err := ln.Accept()
+        if err == nil {
+            c.Close()
+        }
+        accepted <- err
+    }()
+    first, err := net.Dial("tcp", ln.Addr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer first.Close()
+    // The first connection is rejected at capacity and closed
+    first.SetReadDeadline(time.Now().Add(time.Second))
+    _, err = first.Read(make([]byte, 1))
+    if ne, ok := err.(net.Error); err == nil || ok && ne.Timeout() {
+        t.Fatal("rejected connection not closed", err)
+    }
+    jh.Done()
+    second, err := net.Dial("tcp", ln.Addr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer second.Close()
+    if err := <-accepted; err != nil {
+        t.Fatal("Accept gave up after a rejection", err)
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
+
+func TestWithIdleClose(t *testing.T) {
+    jh := New(context.Background())
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ln = jh.WrapListener(ln, WithIdleClose(time.Millisecond))
+    defer ln.Close()
+    client, err := net.Dial("tcp", ln.Addr().String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer client.Close()
+    if _, err := ln.Accept(); err != nil {
+        t.Fatal(err)
+    }
+    jh.Stop()
+    if !jh.WaitTimeout(time.Second) {
+        t.Fatal("idle connection not closed on drain")
+    }
+}
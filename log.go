@@ -0,0 +1,43 @@
+package jobhandler
+
+import(
+    "context"
+    "log/slog"
+    "time"
+)
+
+// WithLogger makes the jobhandler log to l when it is stopped,
+// when jobs are rejected, at debug level, and when it is drained.
+// Records carry the name of the jobhandler as attribute "jobhandler".
+func WithLogger(l *slog.Logger) Option {
+    return func(jh *JobHandler) {
+        jh.logger = l
+    }
+}
+
+// WithSlowJobLog makes the jobhandler log a warning to the logger set by
+// WithLogger for every job that takes longer than d. Only the durations
+// of jobs taken with TryJob, TryNamed and TryFuncGroup are known.
+func WithSlowJobLog(d time.Duration) Option {
+    return func(jh *JobHandler) {
+        jh.slowJob = d
+    }
+}
+
+// log logs msg with args to the logger of the jobhandler, if any.
+func (jh *JobHandler) log(level slog.Level, msg string, args ...any) {
+    if jh.logger == nil {
+        return
+    }
+    if jh.name != "" {
+        args = append(args, "jobhandler", jh.name)
+    }
+    jh.logger.Log(context.Background(), level, msg, args...)
+}
+
+// logSlow logs the job name of kind if it took longer than d.
+func (jh *JobHandler) logSlow(kind, name string, d time.Duration) {
+    if jh.slowJob > 0 && d > jh.slowJob {
+        jh.log(slog.LevelWarn, "jobhandler: slow job", kind, name, "duration", d)
+    }
+}
@@ -0,0 +1,30 @@
+package jobhandler
+import(
+    "context"
+    "log/slog"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestWithLogger(t *testing.T) {
+    var sb strings.Builder
+    logger := slog.New(slog.NewTextHandler(&sb, &slog.HandlerOptions{Level: slog.LevelDebug}))
+    jh := New(context.Background(), WithName("server"), WithLogger(logger), WithSlowJobLog(time.Nanosecond))
+    job, _ := jh.TryNamed("reindex")
+    time.Sleep(time.Millisecond)
+    job.Done()
+    jh.Stop()
+    jh.Try()
+    jh.WaitAll()
+    for _, s := range []string{
+        `msg="jobhandler: slow job" job=reindex`,
+        `msg="jobhandler: stopping" cause="jobhandler: stopped" jobs=0 jobhandler=server`,
+        `msg="jobhandler: rejected job" reason=stopped`,
+        `msg="jobhandler: drained" abandoned=0`,
+    } {
+        if !strings.Contains(sb.String(), s) {
+            t.Fatalf("log does not contain %q:\n%s", s, sb.String())
+        }
+    }
+}
@@ -0,0 +1,82 @@
+package jobhandler
+
+import(
+    "errors"
+    "io/fs"
+    "log/slog"
+    "os"
+)
+
+// A MarkerStore persists whether a jobhandler shut down cleanly,
+// see WithShutdownMarker.
+type MarkerStore interface {
+    // Load returns true if the marker of a clean shutdown is stored.
+    Load() (bool, error)
+    // Store stores the marker if clean is true and removes it if not.
+    Store(clean bool) error
+}
+
+// FileMarker is a MarkerStore that marks a clean shutdown
+// with an empty file at its path.
+type FileMarker string
+
+// Load returns true if the marker file exists.
+func (m FileMarker) Load() (bool, error) {
+    _, err := os.Stat(string(m))
+    if errors.Is(err, fs.ErrNotExist) {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+// Store creates the marker file if clean is true and removes it if not.
+func (m FileMarker) Store(clean bool) error {
+    if !clean {
+        err := os.Remove(string(m))
+        if errors.Is(err, fs.ErrNotExist) {
+            return nil
+        }
+        return err
+    }
+    return os.WriteFile(string(m), nil, 0o644)
+}
+
+// WithShutdownMarker makes New report whether the previous run shut down
+// cleanly, see CleanStart, by loading the marker from store and removing it.
+// The marker is stored again once the jobhandler is drained without
+// abandoned jobs and its AfterDrain hooks are complete, so a crash or
+// forced stop leaves it removed.
+func WithShutdownMarker(store MarkerStore) Option {
+    return func(jh *JobHandler) {
+        jh.marker = store
+    }
+}
+
+// CleanStart returns true if the previous run shut down cleanly,
+// as recorded by the store set by WithShutdownMarker.
+// The error is the error of loading or removing the marker, if any.
+// Without a store, CleanStart returns false.
+func (jh *JobHandler) CleanStart() (bool, error) {
+    return jh.cleanStart, jh.markerErr
+}
+
+// loadMarker loads and removes the shutdown marker, if a store is set.
+func (jh *JobHandler) loadMarker() {
+    if jh.marker == nil {
+        return
+    }
+    jh.cleanStart, jh.markerErr = jh.marker.Load()
+    if err := jh.marker.Store(false); err != nil && jh.markerErr == nil {
+        jh.markerErr = err
+    }
+}
+
+// storeMarker stores the shutdown marker if s describes a clean shutdown.
+func (jh *JobHandler) storeMarker(s Summary) {
+    if jh.marker == nil || s.Abandoned > 0 {
+        return
+    }
+    if err := jh.marker.Store(true); err != nil {
+        jh.log(slog.LevelError, "jobhandler: storing shutdown marker", "error", err)
+    }
+}
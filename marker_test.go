@@ -0,0 +1,29 @@
+package jobhandler
+import(
+    "context"
+    "path/filepath"
+    "testing"
+)
+
+func TestWithShutdownMarker(t *testing.T) {
+    marker := FileMarker(filepath.Join(t.TempDir(), "clean"))
+    jh := New(context.Background(), WithShutdownMarker(marker))
+    if clean, err := jh.CleanStart(); clean || err != nil {
+        t.Fatal("unexpected clean start", clean, err)
+    }
+    jh.Stop()
+    jh.WaitAll()
+    jh = New(context.Background(), WithShutdownMarker(marker))
+    if clean, err := jh.CleanStart(); !clean || err != nil {
+        t.Fatal("unexpected dirty start", clean, err)
+    }
+    jh.Try()
+    jh.StopWithTimeout(0)
+    jh.WaitAll()
+    jh = New(context.Background(), WithShutdownMarker(marker))
+    if clean, err := jh.CleanStart(); clean || err != nil {
+        t.Fatal("clean start after forced stop", clean, err)
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
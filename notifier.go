@@ -0,0 +1,38 @@
+package jobhandler
+
+import(
+    "sync"
+    "sync/atomic"
+)
+
+// A notifier wakes the goroutines waiting for a change, like a condition
+// variable that can be waited on in a select.
+// Waiters register in waiters, so notify is a single load without them.
+type notifier struct {
+    waiters atomic.Int64
+    mu      sync.Mutex
+    ch      chan struct{}
+}
+
+// wait returns a channel that is closed by the next notify.
+func (n *notifier) wait() <-chan struct{} {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    if n.ch == nil {
+        n.ch = make(chan struct{})
+    }
+    return n.ch
+}
+
+// notify wakes all goroutines waiting on channels returned by wait.
+func (n *notifier) notify() {
+    if n.waiters.Load() == 0 {
+        return
+    }
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    if n.ch != nil {
+        close(n.ch)
+        n.ch = nil
+    }
+}
@@ -0,0 +1,39 @@
+package jobhandler
+
+// TryOrElse is like TryFunc, but if the job is rejected, fallback is run
+// instead, untracked by the jobhandler, e.g. to enqueue the work to an
+// external queue or return a cached response.
+// Returns true if fn was run and false if fallback was run.
+func (jh *JobHandler) TryOrElse(fn, fallback func(), opts ...JobOption) bool {
+    if jh.TryFunc(fn, opts...) {
+        return true
+    }
+    fallback()
+    return false
+}
+
+// TryFuncAsyncOrElse is like TryFuncAsync, but if the job is rejected,
+// fallback is run asynchronously instead, untracked by the jobhandler,
+// see TryOrElse. The channel sends false after fallback returns.
+func (jh *JobHandler) TryFuncAsyncOrElse(fn, fallback func(), opts ...JobOption) <-chan bool {
+    return jh.tryFuncAsync(fn, fallback, opts)
+}
+
+// TryNFuncAsyncOrElse is like TryNFuncAsync, but if the jobs are rejected,
+// fallback is run asynchronously instead, untracked by the jobhandler,
+// see TryOrElse. The channel sends false after fallback returns.
+func (jh *JobHandler) TryNFuncAsyncOrElse(delta, limit int, fn func(int), fallback func(), opts ...JobOption) <-chan bool {
+    return jh.tryNFuncAsync(delta, limit, fn, fallback, nil, opts)
+}
+
+// fallback sends false on ch, after running fn asynchronously if not nil.
+func (jh *JobHandler) fallback(fn func(), ch chan<- bool) {
+    if fn == nil {
+        ch <- false
+        return
+    }
+    go func() {
+        fn()
+        ch <- false
+    }()
+}
@@ -0,0 +1,27 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+)
+
+func TestTryOrElse(t *testing.T) {
+    jh := New(context.Background())
+    var ran, fellBack int
+    if !jh.TryOrElse(func() { ran++ }, func() { fellBack++ }) {
+        t.Fatal("expected job to run")
+    }
+    jh.Stop()
+    if jh.TryOrElse(func() { ran++ }, func() { fellBack++ }) {
+        t.Fatal("expected fallback to run after stop")
+    }
+    if <-jh.TryFuncAsyncOrElse(func() { ran++ }, func() { fellBack++ }) {
+        t.Fatal("expected async fallback to run after stop")
+    }
+    if <-jh.TryNFuncAsyncOrElse(2, 1, func(int) { ran++ }, func() { fellBack++ }) {
+        t.Fatal("expected batch fallback to run after stop")
+    }
+    if ran != 1 || fellBack != 3 {
+        t.Fatal("unexpected runs", ran, fellBack)
+    }
+    jh.WaitAll()
+}
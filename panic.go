@@ -0,0 +1,33 @@
+package jobhandler
+
+import(
+    "fmt"
+    "runtime/debug"
+)
+
+// A PanicError is a panic recovered from a job,
+// returned by the functions that report job errors.
+type PanicError struct {
+    // Value is the value passed to panic.
+    Value any
+    // Stack is the stack trace of the panicking goroutine.
+    Stack []byte
+}
+
+func (e *PanicError) Error() string {
+    return fmt.Sprintf("job panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+    err, _ := e.Value.(error)
+    return err
+}
+
+// recoverError recovers a panic into *perr as a *PanicError.
+// It must be deferred directly.
+func recoverError(perr *error) {
+    if r := recover(); r != nil {
+        *perr = &PanicError{Value: r, Stack: debug.Stack()}
+    }
+}
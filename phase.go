@@ -0,0 +1,69 @@
+package jobhandler
+
+import(
+    "context"
+    "slices"
+    "strconv"
+)
+
+// Phase returns the jobhandler of shutdown phase n of jh, creating it with
+// opts on first use. It is named "phase<n>" like a child, see NewChild, e.g.
+//
+//     ingest := jh.Phase(0)
+//     writers := jh.Phase(1)
+//     db := jh.Phase(2)
+//
+// Unlike children, phases are not stopped together with jh. Once jh is
+// stopped, its phases are stopped in ascending order with the stop cause
+// of jh, each after the previous phase is drained.
+// Each phase counts as a single job of jh until it is drained,
+// so jh.WaitAll also waits for all phases.
+// If jh is stopped, a new phase is returned stopped.
+func (jh *JobHandler) Phase(n int, opts ...Option) *JobHandler {
+    jh.mu.Lock()
+    if p := jh.phases[n]; p != nil {
+        jh.mu.Unlock()
+        return p
+    }
+    name := "phase" + strconv.Itoa(n)
+    if jh.name != "" {
+        name = jh.name + "." + name
+    }
+    p := New(context.Background(), append(opts[:len(opts):len(opts)], WithName(name))...)
+    if jh.phases == nil {
+        jh.phases = make(map[int]*JobHandler)
+        if jh.stopChan != nil {
+            go jh.stopPhases(jh.stopChan)
+        }
+    }
+    jh.phases[n] = p
+    stopping := jh.phasesStopping
+    jh.mu.Unlock()
+    if stopping {
+        p.stop(jh.StopCause())
+    }
+    jh.adopt(p)
+    return p
+}
+
+// stopPhases stops the phases of jh in order once stopChan is closed.
+func (jh *JobHandler) stopPhases(stopChan chan struct{}) {
+    <-stopChan
+    jh.mu.Lock()
+    jh.phasesStopping = true
+    ns := make([]int, 0, len(jh.phases))
+    for n := range jh.phases {
+        ns = append(ns, n)
+    }
+    slices.Sort(ns)
+    phases := make([]*JobHandler, len(ns))
+    for i, n := range ns {
+        phases[i] = jh.phases[n]
+    }
+    jh.mu.Unlock()
+    cause := jh.StopCause()
+    for _, p := range phases {
+        p.stop(cause)
+        p.WaitAll()
+    }
+}
@@ -0,0 +1,34 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+)
+
+func TestPhase(t *testing.T) {
+    jh := New(context.Background(), WithName("server"))
+    db := jh.Phase(2)
+    ingest := jh.Phase(0)
+    if jh.Phase(0) != ingest || ingest.Name() != "server.phase0" {
+        t.Fatal("unexpected phase", ingest.Name())
+    }
+    ingest.Try()
+    db.Try()
+    jh.Stop()
+    <-ingest.OnStop()
+    if db.Stopped() {
+        t.Fatal("later phase stopped before earlier phase drained")
+    }
+    ingest.Done()
+    <-db.OnStop()
+    if db.StopCause() != ErrStopped {
+        t.Fatal("unexpected cause", db.StopCause())
+    }
+    if jh.State() == Stopped {
+        t.Fatal("drained before its phases")
+    }
+    db.Done()
+    jh.WaitAll()
+    if !jh.Phase(1).Stopped() {
+        t.Fatal("new phase of stopped jobhandler is running")
+    }
+}
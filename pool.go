@@ -0,0 +1,129 @@
+package jobhandler
+
+import(
+    "context"
+    "runtime"
+    "sync"
+    "time"
+)
+
+// A Pool runs a single worker function on the payloads submitted to it,
+// on a fixed set of long-lived workers, instead of spawning a goroutine
+// per job like TryNFuncAsync. The payloads are passed as values
+// and not wrapped in closures.
+type Pool[T any] struct {
+    jh      *JobHandler
+    fn      func(context.Context, T)
+    payload chan T
+    workers int
+    share   float64
+    mu      sync.Mutex
+    // drained is the drained channel of the run of jh the workers run for
+    drained chan struct{}
+}
+
+// NewPool starts a pool of workers goroutines running fn as jobs of jh.
+// fn is passed a context that is cancelled when jh is stopped.
+// If workers is <= 0, the pool has one worker per CPU usable by the
+// process, see runtime.GOMAXPROCS.
+// The workers exit once jh is stopped and all of its jobs are done,
+// and are started again by Submit if jh is re-armed, see Reset.
+func NewPool[T any](jh *JobHandler, workers int, fn func(context.Context, T)) *Pool[T] {
+    if workers <= 0 {
+        workers = runtime.GOMAXPROCS(0)
+    }
+    p := &Pool[T]{
+        jh:      jh,
+        fn:      fn,
+        payload: make(chan T),
+        workers: workers,
+    }
+    if !jh.Stopped() {
+        p.start()
+    }
+    return p
+}
+
+// NewBackgroundPool is like NewPool, but starts a single worker for
+// low-priority background jobs, like housekeeping, that pauses between jobs
+// to cap its share of one CPU to share, e.g. 0.1 for 10%.
+// The share is approximated by the wall time of the jobs:
+// a job that runs for d is followed by a pause of d*(1-share)/share.
+// The pauses end when jh is stopped, so the pool drains at full speed.
+func NewBackgroundPool[T any](jh *JobHandler, share float64, fn func(context.Context, T)) *Pool[T] {
+    p := &Pool[T]{
+        jh:      jh,
+        fn:      fn,
+        payload: make(chan T),
+        workers: 1,
+        share:   share,
+    }
+    if !jh.Stopped() {
+        p.start()
+    }
+    return p
+}
+
+// Workers returns the number of workers of the pool.
+func (p *Pool[T]) Workers() int {
+    return p.workers
+}
+
+// start starts the workers for the current run of the jobhandler,
+// unless they are already started.
+func (p *Pool[T]) start() {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.drained == p.jh.drainedChan {
+        return
+    }
+    ctx, drained := p.jh.Context(), p.jh.drainedChan
+    p.drained = drained
+    for i := 0; i < p.workers; i++ {
+        go p.jh.runJob("", func() { p.work(ctx, drained) })
+    }
+}
+
+func (p *Pool[T]) work(ctx context.Context, drained <-chan struct{}) {
+    for {
+        select {
+        case v := <-p.payload:
+            start := p.jh.clock().Now()
+            p.run(ctx, v)
+            p.jh.Done()
+            p.pause(p.jh.clock().Now().Sub(start))
+        case <-drained:
+            return
+        }
+    }
+}
+
+// pause pauses a background worker after a job that ran for d.
+func (p *Pool[T]) pause(d time.Duration) {
+    if p.share <= 0 || p.share >= 1 {
+        return
+    }
+    p.jh.TrySleep(time.Duration(float64(d) * (1 - p.share) / p.share))
+}
+
+// run runs the worker function on v like runJob runs a job.
+func (p *Pool[T]) run(ctx context.Context, v T) {
+    if p.jh.panicHandler != nil {
+        defer p.jh.recoverPanic()
+    }
+    p.fn(ctx, v)
+}
+
+// Submit takes on a single job and runs the worker function on v
+// on the next idle worker, blocking until a worker is idle.
+// Returns false if the jobhandler is stopped, in which case v is dropped.
+// Do not call Done(), the job is automatically flagged as done
+// after the worker function exits.
+func (p *Pool[T]) Submit(v T) bool {
+    if !p.jh.Try() {
+        return false
+    }
+    p.start()
+    p.payload <- v
+    return true
+}
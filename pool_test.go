@@ -0,0 +1,74 @@
+package jobhandler
+import(
+    "context"
+    "runtime"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestPool(t *testing.T) {
+    jh := New(context.Background())
+    var sum atomic.Int64
+    p := NewPool(jh, 4, func(ctx context.Context, v int) {
+        sum.Add(int64(v))
+    })
+    for i := 1; i <= 100; i++ {
+        if !p.Submit(i) {
+            t.Fatal("failed to submit")
+        }
+    }
+    jh.Stop()
+    if p.Submit(1) {
+        t.Fatal("submitted to a stopped jobhandler")
+    }
+    jh.WaitAll()
+    if n := sum.Load(); n != 5050 {
+        t.Fatal("unexpected sum of payloads", n)
+    }
+    if n := NewPool(jh, 0, func(context.Context, int) {}).Workers(); n != runtime.GOMAXPROCS(0) {
+        t.Fatal("unexpected default number of workers", n)
+    }
+    var zero JobHandler
+    if NewPool(&zero, 1, func(context.Context, int) {}).Submit(1) {
+        t.Fatal("submitted to a zero jobhandler")
+    }
+}
+
+func TestNewBackgroundPool(t *testing.T) {
+    jh := New(context.Background())
+    p := NewBackgroundPool(jh, 0.5, func(ctx context.Context, d time.Duration) {
+        time.Sleep(d)
+    })
+    start := time.Now()
+    for i := 0; i < 5; i++ {
+        p.Submit(2 * time.Millisecond)
+    }
+    elapsed := time.Since(start)
+    jh.Stop()
+    jh.WaitAll()
+    // Four jobs and their pauses run before the last job is taken
+    if elapsed < 12 * time.Millisecond {
+        t.Fatal("background pool used more than its share", elapsed)
+    }
+}
+
+func TestPoolReset(t *testing.T) {
+    jh := New(context.Background())
+    var n atomic.Int32
+    p := NewPool(jh, 2, func(ctx context.Context, v int) {
+        n.Add(int32(v))
+    })
+    p.Submit(1)
+    jh.Stop()
+    jh.WaitAll()
+    jh.Reset()
+    if !p.Submit(2) {
+        t.Fatal("failed to submit after reset")
+    }
+    jh.Stop()
+    jh.WaitAll()
+    if v := n.Load(); v != 3 {
+        t.Fatal("unexpected sum", v)
+    }
+}
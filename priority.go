@@ -0,0 +1,69 @@
+package jobhandler
+
+// Priority is the priority of a job taken with TryPriority.
+type Priority int
+
+const (
+    // Low priority jobs are shed first, e.g. prefetching.
+    PriorityLow Priority = iota
+    // Normal priority jobs are taken like by Try.
+    PriorityNormal
+    // High priority jobs are shed last.
+    PriorityHigh
+    // Critical priority jobs are taken like by TryCritical,
+    // so they are still admitted while the jobhandler drains.
+    PriorityCritical
+)
+
+func (p Priority) String() string {
+    switch p {
+    case PriorityLow:
+        return "low"
+    case PriorityNormal:
+        return "normal"
+    case PriorityHigh:
+        return "high"
+    case PriorityCritical:
+        return "critical"
+    }
+    return "unknown"
+}
+
+// WithPriorityThreshold makes TryPriority reject jobs of priority p once
+// the jobs that are not done reach fraction of the bound set by
+// WithMaxConcurrent, e.g. 0.8 to shed low priority jobs at 80% load.
+// Without a threshold, jobs of p are rejected at the bound like by Try.
+// Thresholds do not apply without WithMaxConcurrent, or to PriorityCritical.
+func WithPriorityThreshold(p Priority, fraction float64) Option {
+    return func(jh *JobHandler) {
+        if jh.thresholds == nil {
+            jh.thresholds = make(map[Priority]float64)
+        }
+        jh.thresholds[p] = fraction
+    }
+}
+
+// TryPriority attempts to take on a single job of priority p like Try,
+// but sheds the job if the jobhandler is above the threshold of p,
+// see WithPriorityThreshold. Jobs of PriorityCritical are taken with
+// TryCritical instead.
+// Returns true if job is successfully taken
+// and false if the JobHandler is stopped or the job is shed.
+// When the job is done call the Done() method.
+func (jh *JobHandler) TryPriority(p Priority) bool {
+    return jh.tryPriority(p, "")
+}
+
+// tryPriority takes on a single job of priority p
+// and the job class class, which may be empty.
+func (jh *JobHandler) tryPriority(p Priority, class string) bool {
+    if p >= PriorityCritical && jh.Stopped() {
+        return jh.tryCritical(class)
+    }
+    limit := jh.maxConcurrent.Load()
+    if f, ok := jh.thresholds[p]; ok && p < PriorityCritical && limit > 0 &&
+        !jh.Stopped() && float64(jh.inFlight()) >= f * float64(limit) {
+        return jh.reject("priority " + p.String() + " shed")
+    }
+    return jh.tryN(1, class)
+}
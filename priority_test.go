@@ -0,0 +1,39 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+)
+
+func TestTryPriority(t *testing.T) {
+    jh := New(context.Background(), WithMaxConcurrent(4), WithCriticalJobs(1),
+        WithPriorityThreshold(PriorityLow, 0.5), WithPriorityThreshold(PriorityNormal, 0.75))
+    if !jh.TryPriority(PriorityLow) || !jh.TryPriority(PriorityLow) {
+        t.Fatal("expected low priority jobs to be taken below threshold")
+    }
+    if jh.TryPriority(PriorityLow) {
+        t.Fatal("expected low priority job to be shed at threshold")
+    }
+    if !jh.TryPriority(PriorityNormal) {
+        t.Fatal("expected normal priority job to be taken below threshold")
+    }
+    if jh.TryPriority(PriorityNormal) {
+        t.Fatal("expected normal priority job to be shed at threshold")
+    }
+    if !jh.TryPriority(PriorityHigh) {
+        t.Fatal("expected high priority job to be taken below limit")
+    }
+    if jh.TryPriority(PriorityHigh) {
+        t.Fatal("expected high priority job to be rejected at limit")
+    }
+    jh.Stop()
+    if jh.TryPriority(PriorityHigh) {
+        t.Fatal("expected high priority job to be rejected while draining")
+    }
+    if !jh.TryPriority(PriorityCritical) {
+        t.Fatal("expected critical job to be taken while draining")
+    }
+    for i := 0; i < 5; i++ {
+        jh.Done()
+    }
+    jh.WaitAll()
+}
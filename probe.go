@@ -0,0 +1,141 @@
+package jobhandler
+
+import(
+    "context"
+    "errors"
+    "log/slog"
+    "slices"
+    "time"
+)
+
+// defaultProbeInterval is the interval between probe rounds,
+// unless set by WithProbeInterval.
+const defaultProbeInterval = 10 * time.Second
+
+// errNotProbed is the error of probes that have not yet run.
+var errNotProbed = errors.New("jobhandler: not probed yet")
+
+type probe struct {
+    name  string
+    fn    func(context.Context) error
+    err   error
+    since time.Time
+}
+
+// A ProbeStatus is the status of a readiness probe, see Health.
+type ProbeStatus struct {
+    Name string
+    // Err is the error of the latest run of the probe, or nil if it passed.
+    Err error
+    // Since is when the probe started passing or failing.
+    Since time.Time
+}
+
+// Health describes the readiness of a jobhandler, see Health.
+type Health struct {
+    Ready  bool
+    State  State
+    Probes []ProbeStatus
+}
+
+// WithProbe registers the readiness probe fn of a dependency, e.g. a
+// database ping. While any probe fails, the jobhandler is paused:
+// Ready returns false and jobs are rejected. Probes are run in a round
+// once the jobhandler is created and then every probe interval, see
+// WithProbeInterval, so the jobhandler only starts taking on jobs once
+// all probes pass. fn is passed a context that is cancelled after the
+// probe interval or when the jobhandler is stopped.
+func WithProbe(name string, fn func(ctx context.Context) error) Option {
+    return func(jh *JobHandler) {
+        jh.probes = append(jh.probes, &probe{name: name, fn: fn})
+    }
+}
+
+// WithProbeInterval sets the interval between rounds of the probes
+// registered with WithProbe. The default is 10 seconds.
+func WithProbeInterval(d time.Duration) Option {
+    return func(jh *JobHandler) {
+        jh.probeInterval = d
+    }
+}
+
+// Health returns the readiness of the jobhandler
+// and the status of each probe, see WithProbe.
+func (jh *JobHandler) Health() Health {
+    h := Health{Ready: jh.Ready(), State: jh.State()}
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    for _, p := range jh.probes {
+        h.Probes = append(h.Probes, ProbeStatus{Name: p.name, Err: p.err, Since: p.since})
+    }
+    return h
+}
+
+// startProbes pauses the jobhandler and runs the probe rounds
+// until the jobhandler is stopped.
+func (jh *JobHandler) startProbes() {
+    if len(jh.probes) == 0 {
+        return
+    }
+    now := jh.clock().Now()
+    jh.mu.Lock()
+    for _, p := range jh.probes {
+        p.err = errNotProbed
+        p.since = now
+    }
+    jh.mu.Unlock()
+    jh.paused.Store(true)
+    interval := jh.probeInterval
+    if interval <= 0 {
+        interval = defaultProbeInterval
+    }
+    stopChan := jh.stopChan
+    go func() {
+        for {
+            jh.runProbes(interval)
+            t := jh.clock().NewTimer(interval)
+            select {
+            case <-t.C():
+            case <-stopChan:
+                t.Stop()
+                return
+            }
+        }
+    }()
+}
+
+// runProbes runs a round of the probes and pauses the jobhandler
+// if any of them fails.
+func (jh *JobHandler) runProbes(timeout time.Duration) {
+    jh.mu.Lock()
+    probes := slices.Clone(jh.probes)
+    jh.mu.Unlock()
+    ctx, cancel := context.WithTimeout(jh.Context(), timeout)
+    defer cancel()
+    errs := make([]error, len(probes))
+    for i, p := range probes {
+        errs[i] = p.fn(ctx)
+    }
+    now := jh.clock().Now()
+    failing := -1
+    jh.mu.Lock()
+    for i, p := range probes {
+        if (p.err == nil) != (errs[i] == nil) {
+            p.since = now
+        }
+        p.err = errs[i]
+        if p.err != nil && failing < 0 {
+            failing = i
+        }
+    }
+    jh.mu.Unlock()
+    paused := failing >= 0
+    if jh.paused.Swap(paused) == paused {
+        return
+    }
+    if paused {
+        jh.log(slog.LevelWarn, "jobhandler: paused", "probe", probes[failing].name, "err", errs[failing])
+    } else {
+        jh.log(slog.LevelInfo, "jobhandler: resumed")
+    }
+}
@@ -0,0 +1,49 @@
+package jobhandler
+import(
+    "context"
+    "errors"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestWithProbe(t *testing.T) {
+    var failing atomic.Bool
+    failing.Store(true)
+    jh := New(context.Background(), WithProbeInterval(time.Millisecond),
+        WithProbe("db", func(ctx context.Context) error {
+            if failing.Load() {
+                return errors.New("db down")
+            }
+            return nil
+        }))
+    if jh.Ready() || jh.Try() {
+        t.Fatal("ready before the probes passed")
+    }
+    await := func(ready bool) {
+        deadline := time.Now().Add(time.Second)
+        for jh.Ready() != ready {
+            if time.Now().After(deadline) {
+                t.Fatal("expected ready to become", ready)
+            }
+            time.Sleep(time.Millisecond)
+        }
+    }
+    failing.Store(false)
+    await(true)
+    if !jh.Try() {
+        t.Fatal("rejected a job after the probes passed")
+    }
+    jh.Done()
+    failing.Store(true)
+    await(false)
+    h := jh.Health()
+    if h.Ready || len(h.Probes) != 1 || h.Probes[0].Name != "db" || h.Probes[0].Err == nil {
+        t.Fatal("unexpected health", h)
+    }
+    if jh.Try() {
+        t.Fatal("took a job while paused")
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
@@ -0,0 +1,165 @@
+package jobhandler
+
+import(
+    "sync/atomic"
+)
+
+// A QueuePolicy decides what happens to queued jobs when the jobhandler
+// is stopped, see WithQueue.
+type QueuePolicy int
+
+const (
+    // QueueDrop drops the queued jobs without running them.
+    // The dropped jobs are counted in Stats.
+    QueueDrop QueuePolicy = iota
+    // QueueFlush runs all queued jobs at once, regardless of the bounds
+    // of WithMaxConcurrent and WithBudget, and the jobhandler drains
+    // once they are done.
+    QueueFlush
+)
+
+// jobQueue is a bounded FIFO queue of jobs waiting for capacity.
+// The queue is guarded by the mutex of the jobhandler.
+type jobQueue struct {
+    depth       int
+    policy      QueuePolicy
+    fns         []func()
+    dispatching bool
+}
+
+// WithQueue makes Enqueue park up to depth jobs in a FIFO queue while
+// the jobs do not fit the bounds of WithMaxConcurrent or WithBudget,
+// instead of rejecting them. Queued jobs are started in order as jobs
+// are done. When the jobhandler is stopped, the queued jobs are dropped
+// or flushed according to policy.
+func WithQueue(depth int, policy QueuePolicy) Option {
+    return func(jh *JobHandler) {
+        jh.queue = &jobQueue{depth: depth, policy: policy}
+    }
+}
+
+// Enqueue is like TryFuncAsync, but if the job does not fit the bounds
+// of WithMaxConcurrent or WithBudget, it is queued to run once it fits,
+// see WithQueue. Returns true if the job is started or queued
+// and false if the jobhandler is stopped or the queue is full.
+// Do not call Done(), the job is automatically
+// flagged as done after fn exits.
+func (jh *JobHandler) Enqueue(fn func()) bool {
+    reason := jh.admit(1, "")
+    if reason == "" {
+        go jh.runQueued(fn)
+        return true
+    }
+    q := jh.queue
+    if q == nil || (reason != reasonBudget && reason != reasonConcurrent) {
+        return jh.reject(reason)
+    }
+    jh.mu.Lock()
+    if len(q.fns) >= q.depth || jh.Stopped() {
+        jh.mu.Unlock()
+        return jh.reject("queue full")
+    }
+    q.fns = append(q.fns, fn)
+    dispatch := !q.dispatching
+    q.dispatching = true
+    jh.mu.Unlock()
+    if dispatch {
+        go jh.dispatch()
+    }
+    return true
+}
+
+// Queued returns the number of jobs in the queue, see WithQueue.
+func (jh *JobHandler) Queued() int {
+    if jh.queue == nil {
+        return 0
+    }
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    return len(jh.queue.fns)
+}
+
+// dispatch starts the queued jobs in order as they fit,
+// until the queue is empty or the jobhandler is stopped.
+func (jh *JobHandler) dispatch() {
+    q := jh.queue
+    jh.capacity.waiters.Add(1)
+    defer jh.capacity.waiters.Add(-1)
+    var budget <-chan struct{}
+    if jh.budget != nil {
+        jh.budget.capacity.waiters.Add(1)
+        defer jh.budget.capacity.waiters.Add(-1)
+    }
+    for {
+        freed := jh.capacity.wait()
+        if jh.budget != nil {
+            budget = jh.budget.capacity.wait()
+        }
+        jh.mu.Lock()
+        if len(q.fns) == 0 {
+            q.dispatching = false
+            jh.mu.Unlock()
+            return
+        }
+        jh.mu.Unlock()
+        reason := jh.admit(1, "")
+        if reason == "" {
+            jh.mu.Lock()
+            if len(q.fns) == 0 {
+                // The queue was flushed or dropped by stop
+                jh.mu.Unlock()
+                jh.Done()
+                continue
+            }
+            fn := q.fns[0]
+            q.fns[0] = nil
+            q.fns = q.fns[1:]
+            jh.mu.Unlock()
+            go jh.runQueued(fn)
+            continue
+        }
+        if reason != reasonBudget && reason != reasonConcurrent {
+            jh.mu.Lock()
+            q.dispatching = false
+            jh.mu.Unlock()
+            return
+        }
+        select {
+        case <-freed:
+        case <-budget:
+        case <-jh.stopCh():
+        }
+    }
+}
+
+// runQueued runs fn as a job taken by Enqueue.
+func (jh *JobHandler) runQueued(fn func()) {
+    jh.runJob("", fn)
+    jh.Done()
+}
+
+// stopQueue drops or flushes the queued jobs of a stopping jobhandler,
+// while it still holds its own placeholder job.
+func (jh *JobHandler) stopQueue() {
+    q := jh.queue
+    if q == nil {
+        return
+    }
+    jh.mu.Lock()
+    fns := q.fns
+    q.fns = nil
+    jh.mu.Unlock()
+    if len(fns) == 0 {
+        return
+    }
+    if q.policy == QueueDrop {
+        jh.dropped.Add(uint64(len(fns)))
+        return
+    }
+    atomic.AddInt64(&jh.n, int64(len(fns)))
+    jh.admitStages(Admission{Delta: len(fns), Forced: true})
+    jh.accepted.Add(uint64(len(fns)))
+    for _, fn := range fns {
+        go jh.runQueued(fn)
+    }
+}
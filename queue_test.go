@@ -0,0 +1,78 @@
+package jobhandler
+import(
+    "context"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestEnqueue(t *testing.T) {
+    t.Run("dispatch", func (t *testing.T) {
+        jh := New(context.Background(), WithMaxConcurrent(1), WithQueue(2, QueueDrop))
+        release := make(chan struct{})
+        var ran atomic.Int32
+        for i := 0; i < 3; i++ {
+            if !jh.Enqueue(func() {
+                <-release
+                ran.Add(1)
+            }) {
+                t.Fatal("expected job", i, "to be started or queued")
+            }
+        }
+        if jh.Enqueue(func() {}) {
+            t.Fatal("expected job to be rejected when the queue is full")
+        }
+        if n := jh.Queued(); n != 2 {
+            t.Fatal("expected 2 queued jobs, got", n)
+        }
+        close(release)
+        for jh.Queued() > 0 || jh.Running() > 0 {
+            time.Sleep(time.Millisecond)
+        }
+        jh.Stop()
+        jh.WaitAll()
+        if n := ran.Load(); n != 3 {
+            t.Fatal("expected 3 jobs to run, got", n)
+        }
+    })
+    t.Run("drop", func (t *testing.T) {
+        jh := New(context.Background(), WithMaxConcurrent(1), WithQueue(2, QueueDrop))
+        release := make(chan struct{})
+        var ran atomic.Int32
+        for i := 0; i < 3; i++ {
+            jh.Enqueue(func() {
+                <-release
+                ran.Add(1)
+            })
+        }
+        jh.Stop()
+        close(release)
+        jh.WaitAll()
+        if n := ran.Load(); n != 1 {
+            t.Fatal("expected 1 job to run, got", n)
+        }
+        if n := jh.Stats().Dropped; n != 2 {
+            t.Fatal("expected 2 dropped jobs, got", n)
+        }
+    })
+    t.Run("flush", func (t *testing.T) {
+        jh := New(context.Background(), WithMaxConcurrent(1), WithQueue(2, QueueFlush))
+        release := make(chan struct{})
+        var ran atomic.Int32
+        for i := 0; i < 3; i++ {
+            jh.Enqueue(func() {
+                <-release
+                ran.Add(1)
+            })
+        }
+        jh.Stop()
+        if n := jh.Queued(); n != 0 {
+            t.Fatal("expected queue to be flushed, got", n)
+        }
+        close(release)
+        jh.WaitAll()
+        if n := ran.Load(); n != 3 {
+            t.Fatal("expected 3 jobs to run, got", n)
+        }
+    })
+}
@@ -0,0 +1,54 @@
+package jobhandler
+
+import(
+    "sync"
+    "time"
+)
+
+// A bucket is a token bucket refilled at rate tokens per second
+// up to burst tokens.
+type bucket struct {
+    mu     sync.Mutex
+    rate   float64
+    burst  float64
+    tokens float64
+    last   time.Time
+}
+
+// WithRateLimit bounds the rate at which the jobhandler takes on jobs to
+// r jobs per second, with bursts of up to burst jobs, e.g. to protect
+// a downstream service. A job taken with TryN(delta) takes delta tokens.
+// Jobs that would exceed the rate are rejected, also by AcquireN, which
+// only waits for capacity. Critical jobs, see TryCritical, are only
+// exempt from the rate once the jobhandler is stopped.
+func WithRateLimit(r float64, burst int) Option {
+    return func(jh *JobHandler) {
+        jh.rate = &bucket{rate: r, burst: float64(burst), tokens: float64(burst)}
+    }
+}
+
+// take takes n tokens from b at now.
+// Returns false if b has less than n tokens.
+func (b *bucket) take(now time.Time, n int) bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if !b.last.IsZero() && now.After(b.last) {
+        b.tokens = min(b.burst, b.tokens + now.Sub(b.last).Seconds() * b.rate)
+    }
+    b.last = now
+    if b.tokens < float64(n) {
+        return false
+    }
+    b.tokens -= float64(n)
+    return true
+}
+
+// refund returns n tokens taken for jobs that were not taken after all.
+func (b *bucket) refund(n int) {
+    if b == nil {
+        return
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.tokens = min(b.burst, b.tokens + float64(n))
+}
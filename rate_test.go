@@ -0,0 +1,45 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+type stepClock struct {
+    systemClock
+    now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+    return c.now
+}
+
+func TestWithRateLimit(t *testing.T) {
+    clk := &stepClock{now: time.Unix(0, 0)}
+    jh := New(context.Background(), WithClock(clk), WithRateLimit(10, 2))
+    if !jh.TryN(2) {
+        t.Fatal("expected burst to be taken")
+    }
+    if jh.Try() {
+        t.Fatal("expected job beyond burst to be rejected")
+    }
+    clk.now = clk.now.Add(100 * time.Millisecond)
+    if !jh.Try() {
+        t.Fatal("expected job to be taken after refill")
+    }
+    if jh.Try() {
+        t.Fatal("expected job to be rejected before next refill")
+    }
+    clk.now = clk.now.Add(time.Hour)
+    if jh.TryN(3) {
+        t.Fatal("expected jobs beyond burst to be rejected after long idle")
+    }
+    if !jh.TryN(2) {
+        t.Fatal("expected refill to be capped at burst")
+    }
+    jh.Stop()
+    for i := 0; i < 5; i++ {
+        jh.Done()
+    }
+    jh.WaitAll()
+}
@@ -0,0 +1,106 @@
+package jobhandler
+
+import(
+    "log/slog"
+    "reflect"
+    "runtime"
+    "strings"
+    "sync"
+    "time"
+)
+
+// A Rejection records a job that was rejected by a jobhandler.
+type Rejection struct {
+    Time     time.Time
+    Reason   string
+    // Function, File and Line locate the caller outside of this package
+    // that attempted to take on the job.
+    Function string
+    File     string
+    Line     int
+}
+
+// rejectLog is a ring buffer of the most recent rejections.
+type rejectLog struct {
+    mu   sync.Mutex
+    buf  []Rejection
+    next int
+    full bool
+}
+
+// WithRejectionLog makes the jobhandler keep the n most recent rejections,
+// including the reason and caller location, see Rejections.
+func WithRejectionLog(n int) Option {
+    return func(jh *JobHandler) {
+        if n > 0 {
+            jh.rejects = &rejectLog{buf: make([]Rejection, n)}
+        }
+    }
+}
+
+// Rejections returns the most recent rejections, oldest first.
+// Returns nil unless the jobhandler is created with WithRejectionLog.
+func (jh *JobHandler) Rejections() []Rejection {
+    l := jh.rejects
+    if l == nil {
+        return nil
+    }
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if !l.full {
+        return append([]Rejection(nil), l.buf[:l.next]...)
+    }
+    return append(append([]Rejection(nil), l.buf[l.next:]...), l.buf[:l.next]...)
+}
+
+var pkgPath = reflect.TypeOf(JobHandler{}).PkgPath()
+
+// reject records a rejection for reason, if enabled, and returns false.
+func (jh *JobHandler) reject(reason string) bool {
+    jh.rejected.Add(1)
+    jh.log(slog.LevelDebug, "jobhandler: rejected job", "reason", reason)
+    l := jh.rejects
+    if l == nil {
+        return false
+    }
+    r := Rejection{
+        Time:   jh.clock().Now(),
+        Reason: reason,
+    }
+    pcs := make([]uintptr, 16)
+    frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+    for {
+        f, more := frames.Next()
+        if !isPkgFunc(f.Function) {
+            r.Function, r.File, r.Line = f.Function, f.File, f.Line
+            break
+        }
+        if !more {
+            break
+        }
+    }
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.buf[l.next] = r
+    l.next++
+    if l.next == len(l.buf) {
+        l.next = 0
+        l.full = true
+    }
+    return false
+}
+
+// isPkgFunc reports whether fn is a function of this package,
+// excluding its tests and examples.
+func isPkgFunc(fn string) bool {
+    name, ok := strings.CutPrefix(fn, pkgPath + ".")
+    if !ok {
+        return false
+    }
+    for _, prefix := range []string{"Test", "Example", "Benchmark", "Fuzz"} {
+        if strings.HasPrefix(name, prefix) {
+            return false
+        }
+    }
+    return true
+}
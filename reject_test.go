@@ -0,0 +1,39 @@
+package jobhandler
+import(
+    "context"
+    "strings"
+    "testing"
+)
+
+func TestRejections(t *testing.T) {
+    t.Run("disabled", func (t *testing.T) {
+        jh := New(context.Background())
+        jh.Stop()
+        jh.Try()
+        if r := jh.Rejections(); r != nil {
+            t.Fatal("unexpected rejections", r)
+        }
+    })
+    t.Run("ring buffer", func (t *testing.T) {
+        jh := New(context.Background(), WithRejectionLog(2))
+        jh.TryN(-1)
+        jh.Stop()
+        jh.Try()
+        jh.TryFunc(func () {})
+        r := jh.Rejections()
+        if len(r) != 2 {
+            t.Fatal("unexpected rejection count", len(r))
+        }
+        for _, rej := range r {
+            if rej.Reason != "stopped" {
+                t.Fatal("unexpected reason", rej.Reason)
+            }
+            if !strings.HasSuffix(rej.Function, "TestRejections.func2") || !strings.HasSuffix(rej.File, "reject_test.go") {
+                t.Fatal("unexpected caller", rej.Function, rej.File)
+            }
+        }
+        if r[0].Line >= r[1].Line {
+            t.Fatal("unexpected order", r[0].Line, r[1].Line)
+        }
+    })
+}
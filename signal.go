@@ -0,0 +1,43 @@
+package jobhandler
+
+import(
+    "os"
+    "os/signal"
+)
+
+// A SignalError is the stop cause of a jobhandler stopped by a signal,
+// see WithSignals.
+type SignalError struct {
+    Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+    return "jobhandler: received signal " + e.Signal.String()
+}
+
+// WithSignals makes the jobhandler stop itself when the process receives
+// one of the signals sigs, e.g. syscall.SIGTERM and os.Interrupt.
+// The stop cause is a *SignalError, see StopCause.
+func WithSignals(sigs ...os.Signal) Option {
+    return func(jh *JobHandler) {
+        jh.signals = append(jh.signals, sigs...)
+    }
+}
+
+// notifySignals stops the jobhandler on the configured signals.
+func (jh *JobHandler) notifySignals() {
+    if len(jh.signals) == 0 {
+        return
+    }
+    ch := make(chan os.Signal, 1)
+    signal.Notify(ch, jh.signals...)
+    stopChan := jh.stopChan
+    go func() {
+        defer signal.Stop(ch)
+        select {
+        case sig := <-ch:
+            jh.stopAfterDelay(&SignalError{Signal: sig})
+        case <-stopChan:
+        }
+    }()
+}
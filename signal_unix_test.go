@@ -0,0 +1,19 @@
+//go:build unix
+
+package jobhandler
+import(
+    "context"
+    "errors"
+    "syscall"
+    "testing"
+)
+
+func TestWithSignals(t *testing.T) {
+    jh := New(context.Background(), WithSignals(syscall.SIGUSR2))
+    syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+    jh.WaitAll()
+    var serr *SignalError
+    if !errors.As(jh.Err(), &serr) || serr.Signal != syscall.SIGUSR2 {
+        t.Fatal("unexpected stop cause", jh.Err())
+    }
+}
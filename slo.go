@@ -0,0 +1,69 @@
+package jobhandler
+
+import(
+    "time"
+)
+
+// SLOStats describes how the jobs of a group perform against
+// their service level objective, see SetSLO.
+type SLOStats struct {
+    // Target is the duration jobs should complete within.
+    Target time.Duration
+    // Objective is the fraction of jobs that should complete within Target.
+    Objective float64
+    // Total is the number of completed jobs.
+    Total uint64
+    // Exceeded is the number of completed jobs that took longer than Target.
+    Exceeded uint64
+}
+
+// BurnRate returns how fast the error budget of the objective is consumed:
+// the fraction of jobs exceeding the target divided by the fraction allowed to.
+// A burn rate above 1 means the objective is not met.
+func (s SLOStats) BurnRate() float64 {
+    if s.Total == 0 || s.Objective >= 1 {
+        return 0
+    }
+    return float64(s.Exceeded) / float64(s.Total) / (1 - s.Objective)
+}
+
+// SetSLO declares that jobs of group, see TryFuncGroup, should complete
+// within target in at least the fraction objective of cases, e.g. 0.99.
+// The handler then tracks the jobs of the group exceeding target,
+// exposed in Stats. Setting the SLO of a group again resets its counts.
+func (jh *JobHandler) SetSLO(group string, target time.Duration, objective float64) {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    if jh.slos == nil {
+        jh.slos = make(map[string]*SLOStats)
+    }
+    jh.slos[group] = &SLOStats{Target: target, Objective: objective}
+}
+
+// observeSLO records a job of group that took d.
+func (jh *JobHandler) observeSLO(group string, d time.Duration) {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    s := jh.slos[group]
+    if s == nil {
+        return
+    }
+    s.Total++
+    if d > s.Target {
+        s.Exceeded++
+    }
+}
+
+// sloStats returns a copy of the SLO stats per group.
+func (jh *JobHandler) sloStats() map[string]SLOStats {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    if len(jh.slos) == 0 {
+        return nil
+    }
+    m := make(map[string]SLOStats, len(jh.slos))
+    for group, s := range jh.slos {
+        m[group] = *s
+    }
+    return m
+}
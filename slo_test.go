@@ -0,0 +1,27 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+func TestSLO(t *testing.T) {
+    jh := New(context.Background())
+    jh.SetSLO("reindex", 5 * time.Millisecond, 0.5)
+    jh.TryFuncGroup("reindex", func (ctx context.Context) {})
+    jh.TryFuncGroup("reindex", func (ctx context.Context) { time.Sleep(10 * time.Millisecond) })
+    jh.TryFuncGroup("other", func (ctx context.Context) {})
+    slos := jh.Stats().SLOs
+    if len(slos) != 1 {
+        t.Fatal("unexpected SLOs", slos)
+    }
+    s := slos["reindex"]
+    if s.Total != 2 || s.Exceeded != 1 {
+        t.Fatal("unexpected counts", s.Total, s.Exceeded)
+    }
+    if r := s.BurnRate(); r != 1 {
+        t.Fatal("unexpected burn rate", r)
+    }
+    jh.Stop()
+    jh.WaitAll()
+}
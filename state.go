@@ -0,0 +1,165 @@
+package jobhandler
+
+import(
+    "context"
+    "log/slog"
+    "time"
+)
+
+// State is the lifecycle state of a jobhandler.
+type State int
+
+const (
+    // Running jobhandlers accept new jobs.
+    Running State = iota
+    // Draining jobhandlers are stopped but still have jobs that are not done.
+    Draining
+    // Stopped jobhandlers are stopped and all of their jobs are done.
+    Stopped
+)
+
+func (s State) String() string {
+    switch s {
+    case Running:
+        return "running"
+    case Draining:
+        return "draining"
+    case Stopped:
+        return "stopped"
+    }
+    return "unknown"
+}
+
+// State returns the current lifecycle state of the jobhandler.
+func (jh *JobHandler) State() State {
+    if jh.stopChan == nil {
+        return Stopped
+    }
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    return jh.state
+}
+
+// StateChanges returns a channel that sends the current state of the jobhandler
+// followed by each state transition, Running→Draining→Stopped.
+// The channel is closed after sending Stopped.
+// The channel is buffered, so receiving from it is optional.
+func (jh *JobHandler) StateChanges() <-chan State {
+    ch := make(chan State, Stopped + 1)
+    if jh.stopChan == nil {
+        ch <- Stopped
+        close(ch)
+        return ch
+    }
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    ch <- jh.state
+    if jh.state == Stopped {
+        close(ch)
+    } else {
+        jh.watchers = append(jh.watchers, ch)
+    }
+    return ch
+}
+
+// setState transitions the jobhandler to state s and notifies watchers.
+func (jh *JobHandler) setState(s State) {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    jh.state = s
+    for _, ch := range jh.watchers {
+        ch <- s
+        if s == Stopped {
+            close(ch)
+        }
+    }
+    if s == Stopped {
+        jh.watchers = nil
+    }
+}
+
+// drained is called once the jobhandler is stopped and all jobs are done.
+// Shutdown hooks run between closing drainedChan and stoppedChan.
+// Calls after the first are ignored.
+func (jh *JobHandler) drained() {
+    if !jh.isDrained.CompareAndSwap(false, true) {
+        return
+    }
+    if jh.logger != nil {
+        jh.mu.Lock()
+        abandoned := jh.abandoned
+        jh.mu.Unlock()
+        jh.log(slog.LevelInfo, "jobhandler: drained", "abandoned", abandoned)
+    }
+    jh.endTraceDrain()
+    jh.setState(Stopped)
+    close(jh.drainedChan)
+    jh.runHooks()
+}
+
+// WaitDrained blocks until the jobhandler is stopped and all jobs are done.
+// Unlike WaitStopped, it does not wait for shutdown hooks to complete.
+func (jh *JobHandler) WaitDrained() {
+    if jh.drainedChan != nil {
+        defer jh.waitFor("WaitDrained")()
+        <-jh.drainedChan
+    }
+}
+
+// Drained returns a channel that is closed when the jobhandler is stopped
+// and all jobs are done, like WaitDrained returns. To run a function then,
+// see AfterDrain.
+func (jh *JobHandler) Drained() <-chan struct{} {
+    if jh.drainedChan == nil {
+        return canceledCtx.Done()
+    }
+    return jh.drainedChan
+}
+
+// WaitStopped blocks until the jobhandler is stopped, all jobs are done
+// and all shutdown hooks are complete. WaitAll is the same as WaitStopped.
+func (jh *JobHandler) WaitStopped() {
+    jh.waitStopped("WaitStopped")
+}
+
+// waitStopped is WaitStopped, registered as a waiter blocked in method.
+func (jh *JobHandler) waitStopped(method string) {
+    if jh.stoppedChan != nil {
+        defer jh.waitFor(method)()
+        <-jh.stoppedChan
+    }
+}
+
+// WaitContext is like WaitAll, but gives up when ctx is done.
+// Returns nil if the jobhandler is stopped and all jobs are done,
+// and the context error if ctx is done first.
+func (jh *JobHandler) WaitContext(ctx context.Context) error {
+    if jh.stoppedChan == nil {
+        return nil
+    }
+    defer jh.waitFor("WaitContext")()
+    select {
+    case <-jh.stoppedChan:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+// WaitTimeout is like WaitAll, but gives up after d.
+// Returns true if the jobhandler is stopped and all jobs are done within d,
+// and false if not.
+func (jh *JobHandler) WaitTimeout(d time.Duration) bool {
+    if jh.stoppedChan == nil {
+        return true
+    }
+    defer jh.waitFor("WaitTimeout")()
+    t := jh.clock().NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-jh.stoppedChan:
+        return true
+    case <-t.C():
+        return false
+    }
+}
@@ -0,0 +1,105 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+func TestStateChanges(t *testing.T) {
+    t.Run("transitions", func (t *testing.T) {
+        jh := New(context.Background())
+        ch := jh.StateChanges()
+        if !jh.Try() {
+            t.Fatal("unable to try")
+        }
+        jh.Stop()
+        if s := jh.State(); s != Draining {
+            t.Fatal("unexpected state", s)
+        }
+        jh.Done()
+        jh.WaitAll()
+        var states []State
+        for s := range ch {
+            states = append(states, s)
+        }
+        if len(states) != 3 || states[0] != Running || states[1] != Draining || states[2] != Stopped {
+            t.Fatal("unexpected states", states)
+        }
+    })
+    t.Run("stopped jobhandler", func (t *testing.T) {
+        jh := New(context.Background())
+        jh.Stop()
+        jh.WaitAll()
+        ch := jh.StateChanges()
+        if s := <-ch; s != Stopped {
+            t.Fatal("unexpected state", s)
+        }
+        if _, ok := <-ch; ok {
+            t.Fatal("channel should be closed")
+        }
+    })
+    t.Run("zero jobhandler", func (t *testing.T) {
+        var jh JobHandler
+        if s := jh.State(); s != Stopped {
+            t.Fatal("unexpected state", s)
+        }
+        if s := <-jh.StateChanges(); s != Stopped {
+            t.Fatal("unexpected state", s)
+        }
+    })
+}
+
+func TestWaitDrained(t *testing.T) {
+    jh := New(context.Background())
+    if !jh.Try() {
+        t.Fatal("unable to try")
+    }
+    jh.Stop()
+    go jh.Done()
+    jh.WaitDrained()
+    if s := jh.State(); s != Stopped {
+        t.Fatal("unexpected state", s)
+    }
+    jh.WaitStopped()
+    <-jh.Drained()
+    var zero JobHandler
+    zero.WaitDrained()
+    zero.WaitStopped()
+    <-zero.Drained()
+}
+
+func TestDrained(t *testing.T) {
+    jh := New(context.Background())
+    jh.Try()
+    jh.Stop()
+    select {
+    case <-jh.Drained():
+        t.Fatal("drained with a job outstanding")
+    default:
+    }
+    jh.Done()
+    <-jh.Drained()
+}
+
+func TestWaitTimeout(t *testing.T) {
+    jh := New(context.Background())
+    if !jh.Try() {
+        t.Fatal("unable to try")
+    }
+    jh.Stop()
+    if jh.WaitTimeout(time.Millisecond) {
+        t.Fatal("should time out")
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+    defer cancel()
+    if err := jh.WaitContext(ctx); err != context.DeadlineExceeded {
+        t.Fatal("unexpected error", err)
+    }
+    jh.Done()
+    if !jh.WaitTimeout(time.Second) {
+        t.Fatal("should not time out")
+    }
+    if err := jh.WaitContext(context.Background()); err != nil {
+        t.Fatal("unexpected error", err)
+    }
+}
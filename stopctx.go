@@ -0,0 +1,52 @@
+package jobhandler
+
+import(
+    "context"
+    "time"
+)
+
+// StopContext returns the jobhandler as a context.Context: it is done when
+// the jobhandler is stopped, its deadline is the grace deadline set by
+// StopWithTimeout, if any, and its error wraps the stop cause, see StopCause.
+// Unlike Context, the error matches the stop cause with errors.Is,
+// as well as context.Canceled. StopContext does not allocate.
+func (jh *JobHandler) StopContext() context.Context {
+    return stopContext{jh}
+}
+
+type stopContext struct {
+    jh *JobHandler
+}
+
+func (c stopContext) Deadline() (time.Time, bool) {
+    return c.jh.GraceDeadline()
+}
+
+func (c stopContext) Done() <-chan struct{} {
+    return c.jh.Context().Done()
+}
+
+func (c stopContext) Err() error {
+    cause := c.jh.StopCause()
+    if cause == nil {
+        return nil
+    }
+    return stopError{cause}
+}
+
+func (c stopContext) Value(key any) any {
+    return nil
+}
+
+// stopError is the error of a stopContext.
+type stopError struct {
+    cause error
+}
+
+func (e stopError) Error() string {
+    return e.cause.Error()
+}
+
+func (e stopError) Unwrap() []error {
+    return []error{e.cause, context.Canceled}
+}
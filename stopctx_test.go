@@ -0,0 +1,31 @@
+package jobhandler
+import(
+    "context"
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestStopContext(t *testing.T) {
+    jh := New(context.Background())
+    ctx := jh.StopContext()
+    if ctx.Err() != nil {
+        t.Fatal("done before stop")
+    }
+    if _, ok := ctx.Deadline(); ok {
+        t.Fatal("deadline before stop")
+    }
+    jh.Try()
+    go jh.StopWithTimeout(time.Hour)
+    <-ctx.Done()
+    if err := ctx.Err(); !errors.Is(err, ErrStopped) || !errors.Is(err, context.Canceled) {
+        t.Fatal("unexpected error", err)
+    }
+    if _, ok := ctx.Deadline(); !ok {
+        t.Fatal("missing grace deadline")
+    }
+    jh.Done()
+    jh.WaitAll()
+    var zero JobHandler
+    <-zero.StopContext().Done()
+}
@@ -0,0 +1,80 @@
+package jobhandler
+
+import(
+    "slices"
+)
+
+// An EdgeKind is the kind of an Edge of the jobhandler topology.
+type EdgeKind int
+
+const (
+    // ChildEdge links a jobhandler to a child created by NewChild.
+    ChildEdge EdgeKind = iota
+    // GroupEdge links a jobhandler to one of its running groups.
+    GroupEdge
+)
+
+func (k EdgeKind) String() string {
+    switch k {
+    case ChildEdge:
+        return "child"
+    case GroupEdge:
+        return "group"
+    }
+    return "unknown"
+}
+
+// An Edge of the jobhandler topology, see Topology.
+type Edge struct {
+    Kind EdgeKind
+    // From is the name of the parent jobhandler.
+    From string
+    // To is the name of the child jobhandler or the group.
+    To string
+    // State is the state of the child jobhandler.
+    // For groups it is the state of the parent.
+    State State
+    // Jobs is the number of jobs of the child or group that are not done,
+    // so a draining node with jobs is blocking shutdown.
+    Jobs int64
+}
+
+// Topology returns the edges from jh to its children that are not drained
+// and to its running groups, followed by the edges of the children,
+// recursively. Children are ordered by creation and groups by name.
+func (jh *JobHandler) Topology() []Edge {
+    var edges []Edge
+    jh.mu.Lock()
+    children := slices.Clone(jh.children)
+    groups := make([]string, 0, len(jh.groups))
+    counts := make(map[string]int, len(jh.groups))
+    for name, g := range jh.groups {
+        groups = append(groups, name)
+        counts[name] = len(g.cancels)
+    }
+    jh.mu.Unlock()
+    slices.Sort(groups)
+    for _, child := range children {
+        edges = append(edges, Edge{
+            Kind:  ChildEdge,
+            From:  jh.name,
+            To:    child.name,
+            State: child.State(),
+            Jobs:  child.inFlight(),
+        })
+    }
+    state := jh.State()
+    for _, g := range groups {
+        edges = append(edges, Edge{
+            Kind:  GroupEdge,
+            From:  jh.name,
+            To:    g,
+            State: state,
+            Jobs:  int64(counts[g]),
+        })
+    }
+    for _, child := range children {
+        edges = append(edges, child.Topology()...)
+    }
+    return edges
+}
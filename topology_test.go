@@ -0,0 +1,38 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+)
+
+func TestTopology(t *testing.T) {
+    jh := New(context.Background(), WithName("server"))
+    child := jh.NewChild("ingest")
+    release := make(chan struct{})
+    started := make(chan struct{})
+    go child.TryFuncGroup("tenant42", func (ctx context.Context) {
+        close(started)
+        <-release
+    })
+    <-started
+    child.Stop()
+    want := []Edge{
+        {Kind: ChildEdge, From: "server", To: "server.ingest", State: Draining, Jobs: 1},
+        {Kind: GroupEdge, From: "server.ingest", To: "tenant42", State: Draining, Jobs: 1},
+    }
+    edges := jh.Topology()
+    if len(edges) != len(want) {
+        t.Fatal("unexpected edges", edges)
+    }
+    for i := range want {
+        if edges[i] != want[i] {
+            t.Fatal("unexpected edge", edges[i])
+        }
+    }
+    close(release)
+    child.WaitAll()
+    jh.Stop()
+    jh.WaitAll()
+    if edges := jh.Topology(); len(edges) != 0 {
+        t.Fatal("unexpected edges after drain", edges)
+    }
+}
@@ -0,0 +1,66 @@
+package jobhandler
+
+import(
+    "io"
+)
+
+// SetValue stores val under key as state shared by the jobs of jh,
+// e.g. a rate limiter or a connection pool, replacing any previous value.
+// If val implements io.Closer or has a Close() method, it is closed
+// once the jobhandler is stopped, all jobs are done and all AfterDrain
+// hooks are complete, in reverse order of being set, so it can be used
+// by the jobs and hooks until the end of the drain.
+// Replaced values are closed as well.
+// If the jobhandler is already drained, val is not stored and is closed at once.
+func SetValue[T any](jh *JobHandler, key any, val T) {
+    jh.mu.Lock()
+    if jh.hooksRun || jh.stopChan == nil {
+        jh.mu.Unlock()
+        closeValue(val)
+        return
+    }
+    if jh.values == nil {
+        jh.values = make(map[any]any)
+    }
+    jh.values[key] = val
+    if isCloser(val) {
+        jh.closers = append(jh.closers, val)
+    }
+    jh.mu.Unlock()
+}
+
+// Value returns the value stored under key by SetValue.
+// Returns false if no value of type T is stored under key.
+func Value[T any](jh *JobHandler, key any) (T, bool) {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    v, ok := jh.values[key].(T)
+    return v, ok
+}
+
+func (jh *JobHandler) closeValues() {
+    jh.mu.Lock()
+    closers := jh.closers
+    jh.closers = nil
+    jh.mu.Unlock()
+    for i := len(closers) - 1; i >= 0; i-- {
+        closeValue(closers[i])
+    }
+}
+
+func isCloser(v any) bool {
+    switch v.(type) {
+    case io.Closer, interface{ Close() }:
+        return true
+    }
+    return false
+}
+
+func closeValue(v any) {
+    switch c := v.(type) {
+    case io.Closer:
+        c.Close()
+    case interface{ Close() }:
+        c.Close()
+    }
+}
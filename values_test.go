@@ -0,0 +1,69 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+)
+
+type testCloser struct {
+    name   string
+    closed *[]string
+}
+
+func (c testCloser) Close() {
+    *c.closed = append(*c.closed, c.name)
+}
+
+func TestValues(t *testing.T) {
+    jh := New(context.Background())
+    var closed []string
+    SetValue(jh, "limiter", testCloser{"limiter", &closed})
+    SetValue(jh, "pool", testCloser{"pool", &closed})
+    SetValue(jh, "n", 42)
+    if n, ok := Value[int](jh, "n"); !ok || n != 42 {
+        t.Fatal("unexpected value", n, ok)
+    }
+    if _, ok := Value[string](jh, "n"); ok {
+        t.Fatal("value should not be a string")
+    }
+    if _, ok := Value[int](jh, "missing"); ok {
+        t.Fatal("value should be missing")
+    }
+    if !jh.Try() {
+        t.Fatal("unable to try")
+    }
+    jh.Stop()
+    if len(closed) != 0 {
+        t.Fatal("closed before drain", closed)
+    }
+    jh.Done()
+    jh.WaitAll()
+    if len(closed) != 2 || closed[0] != "pool" || closed[1] != "limiter" {
+        t.Fatal("unexpected close order", closed)
+    }
+    SetValue(jh, "late", testCloser{"late", &closed})
+    if len(closed) != 3 {
+        t.Fatal("late value should be closed at once")
+    }
+}
+
+func TestValuesClosedAfterHooks(t *testing.T) {
+    jh := New(context.Background(), WithHookConcurrency(2))
+    var closed []string
+    SetValue(jh, "pool", testCloser{"pool", &closed})
+    var seen []int
+    for i := 0; i < 2; i++ {
+        jh.AfterDrain(func(Summary) {
+            jh.mu.Lock()
+            seen = append(seen, len(closed))
+            jh.mu.Unlock()
+        })
+    }
+    jh.Stop()
+    jh.WaitAll()
+    if len(seen) != 2 || seen[0] != 0 || seen[1] != 0 {
+        t.Fatal("value closed before hooks completed", seen)
+    }
+    if len(closed) != 1 {
+        t.Fatal("value not closed", closed)
+    }
+}
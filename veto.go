@@ -0,0 +1,79 @@
+package jobhandler
+
+import(
+    "slices"
+    "time"
+)
+
+// defaultVetoWindow bounds how long vetoes defer a requested stop,
+// unless set by WithVetoWindow.
+const defaultVetoWindow = 5 * time.Second
+
+// A StopRequestError is the stop cause of a jobhandler stopped by RequestStop.
+type StopRequestError struct {
+    Reason string
+}
+
+func (e *StopRequestError) Error() string {
+    return "jobhandler: stop requested: " + e.Reason
+}
+
+// WithVetoWindow bounds how long the vetoes registered with OnStopRequest
+// can defer a stop requested with RequestStop. The default is 5 seconds.
+func WithVetoWindow(d time.Duration) Option {
+    return func(jh *JobHandler) {
+        jh.vetoWindow = d
+    }
+}
+
+// OnStopRequest registers veto to be consulted by RequestStop before
+// the jobhandler is stopped, e.g. by a leader election mid-handoff.
+// veto is passed the reason of the request and returns how long to
+// defer the stop, or 0 to let it proceed.
+func (jh *JobHandler) OnStopRequest(veto func(reason string) time.Duration) {
+    jh.mu.Lock()
+    jh.vetoes = append(jh.vetoes, veto)
+    jh.mu.Unlock()
+}
+
+// RequestStop stops the jobhandler once the vetoes registered with
+// OnStopRequest allow it. The vetoes are consulted once, and the stop is
+// deferred by the longest deferral they return, but no longer than the
+// veto window, see WithVetoWindow. The stop cause is a *StopRequestError.
+// The stop then waits out the pre-stop delay like Stop, see WithPreStopDelay,
+// and RequestStop blocks during the delay unless the stop was deferred.
+// Returns true if the request stops the jobhandler, now or
+// after the deferral, and false if the jobhandler is already stopped
+// or a stop is already requested.
+func (jh *JobHandler) RequestStop(reason string) bool {
+    if jh.Stopped() || !jh.stopRequested.CompareAndSwap(false, true) {
+        return false
+    }
+    jh.mu.Lock()
+    vetoes := slices.Clone(jh.vetoes)
+    jh.mu.Unlock()
+    var d time.Duration
+    for _, veto := range vetoes {
+        d = max(d, veto(reason))
+    }
+    window := jh.vetoWindow
+    if window <= 0 {
+        window = defaultVetoWindow
+    }
+    d = min(d, window)
+    cause := &StopRequestError{Reason: reason}
+    if d <= 0 {
+        return jh.stopAfterDelay(cause)
+    }
+    t := jh.clock().NewTimer(d)
+    stopChan := jh.stopChan
+    go func() {
+        defer t.Stop()
+        select {
+        case <-t.C():
+            jh.stopAfterDelay(cause)
+        case <-stopChan:
+        }
+    }()
+    return true
+}
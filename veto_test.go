@@ -0,0 +1,46 @@
+package jobhandler
+import(
+    "context"
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestRequestStop(t *testing.T) {
+    t.Run("allowed", func (t *testing.T) {
+        jh := New(context.Background())
+        jh.OnStopRequest(func(reason string) time.Duration { return 0 })
+        if !jh.RequestStop("deploy") || !jh.Stopped() {
+            t.Fatal("stop was not allowed")
+        }
+        var serr *StopRequestError
+        if !errors.As(jh.StopCause(), &serr) || serr.Reason != "deploy" {
+            t.Fatal("unexpected cause", jh.StopCause())
+        }
+        jh.WaitAll()
+    })
+    t.Run("deferred", func (t *testing.T) {
+        jh := New(context.Background(), WithVetoWindow(10 * time.Millisecond))
+        jh.OnStopRequest(func(reason string) time.Duration { return time.Hour })
+        start := time.Now()
+        if !jh.RequestStop("deploy") {
+            t.Fatal("stop request failed")
+        }
+        if jh.Stopped() {
+            t.Fatal("stop was not deferred")
+        }
+        if jh.RequestStop("again") {
+            t.Fatal("stop requested twice")
+        }
+        jh.WaitAll()
+        if d := time.Since(start); d < 10 * time.Millisecond {
+            t.Fatal("stop deferred for too short", d)
+        }
+    })
+    t.Run("zero jobhandler", func (t *testing.T) {
+        var jh JobHandler
+        if jh.RequestStop("deploy") {
+            t.Fatal("zero jobhandler already stopped")
+        }
+    })
+}
@@ -0,0 +1,63 @@
+package jobhandler
+
+import(
+    "cmp"
+    "slices"
+    "time"
+)
+
+// A WaiterInfo describes a goroutine blocked in WaitAll, WaitDrained
+// or AcquireN, see Waiters. Acquire is listed as AcquireN.
+type WaiterInfo struct {
+    // Method is the blocking method, e.g. "WaitAll".
+    Method  string
+    Since   time.Time
+    Elapsed time.Duration
+}
+
+type waiter struct {
+    id     uint64
+    method string
+    since  time.Time
+}
+
+// waitFor registers the calling goroutine as blocked in method until
+// the returned function is called.
+func (jh *JobHandler) waitFor(method string) (done func()) {
+    w := &waiter{method: method, since: jh.clock().Now()}
+    jh.mu.Lock()
+    if jh.waiters == nil {
+        jh.waiters = make(map[*waiter]struct{})
+    }
+    jh.lastID++
+    w.id = jh.lastID
+    jh.waiters[w] = struct{}{}
+    jh.mu.Unlock()
+    return func() {
+        jh.mu.Lock()
+        delete(jh.waiters, w)
+        jh.mu.Unlock()
+    }
+}
+
+// Waiters lists the goroutines blocked in WaitAll, WaitStopped,
+// WaitContext, WaitTimeout, WaitDrained and AcquireN, longest
+// waiting first, so deadlock triage can tell whether anybody is waiting
+// and for how long.
+func (jh *JobHandler) Waiters() []WaiterInfo {
+    now := jh.clock().Now()
+    jh.mu.Lock()
+    waiters := make([]*waiter, 0, len(jh.waiters))
+    for w := range jh.waiters {
+        waiters = append(waiters, w)
+    }
+    jh.mu.Unlock()
+    slices.SortFunc(waiters, func(a, b *waiter) int {
+        return cmp.Compare(a.id, b.id)
+    })
+    infos := make([]WaiterInfo, len(waiters))
+    for i, w := range waiters {
+        infos[i] = WaiterInfo{Method: w.method, Since: w.since, Elapsed: now.Sub(w.since)}
+    }
+    return infos
+}
@@ -0,0 +1,39 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+func TestWaiters(t *testing.T) {
+    jh := New(context.Background(), WithMaxConcurrent(1))
+    if ws := jh.Waiters(); len(ws) != 0 {
+        t.Fatal("expected no waiters, got", ws)
+    }
+    jh.Try()
+    go jh.WaitAll()
+    go jh.Acquire(context.Background())
+    deadline := time.Now().Add(time.Second)
+    for len(jh.Waiters()) < 2 {
+        if time.Now().After(deadline) {
+            t.Fatal("expected 2 waiters, got", jh.Waiters())
+        }
+        time.Sleep(time.Millisecond)
+    }
+    methods := map[string]bool{}
+    for _, w := range jh.Waiters() {
+        methods[w.Method] = true
+    }
+    if !methods["WaitAll"] || !methods["AcquireN"] {
+        t.Fatal("unexpected waiters", jh.Waiters())
+    }
+    jh.Stop()
+    jh.Done()
+    jh.WaitAll()
+    for len(jh.Waiters()) > 0 {
+        if time.Now().After(deadline) {
+            t.Fatal("expected no waiters, got", jh.Waiters())
+        }
+        time.Sleep(time.Millisecond)
+    }
+}
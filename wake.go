@@ -0,0 +1,71 @@
+package jobhandler
+
+import(
+    "time"
+)
+
+// sleepers are the jobs sleeping in TrySleepNamed under a name.
+type sleepers struct {
+    wake chan struct{}
+    n    int
+}
+
+// TrySleepNamed is like TrySleep, but the sleep can be ended early
+// by Wake(name), e.g. to run a polling loop now instead of waiting out
+// its interval:
+//
+//     for jh.TrySleepNamed("poll", time.Minute) {
+//         poll()
+//     }
+//
+// Returns true if the sleep was done or woken, and false if the jobhandler
+// was stopped first.
+func (jh *JobHandler) TrySleepNamed(name string, d time.Duration) bool {
+    if jh.Stopped() {
+        return false
+    }
+    jh.mu.Lock()
+    if jh.sleepers == nil {
+        jh.sleepers = make(map[string]*sleepers)
+    }
+    s := jh.sleepers[name]
+    if s == nil {
+        s = &sleepers{wake: make(chan struct{})}
+        jh.sleepers[name] = s
+    }
+    s.n++
+    jh.mu.Unlock()
+    defer func() {
+        jh.mu.Lock()
+        s.n--
+        if s.n == 0 && jh.sleepers[name] == s {
+            delete(jh.sleepers, name)
+        }
+        jh.mu.Unlock()
+    }()
+    t := jh.clock().NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-jh.stopCh():
+        return false
+    case <-s.wake:
+        return true
+    case <-t.C():
+        return true
+    }
+}
+
+// Wake ends the sleeps of all jobs sleeping in TrySleepNamed under name,
+// without stopping the jobhandler.
+// Returns the number of woken jobs.
+func (jh *JobHandler) Wake(name string) int {
+    jh.mu.Lock()
+    defer jh.mu.Unlock()
+    s := jh.sleepers[name]
+    if s == nil {
+        return 0
+    }
+    delete(jh.sleepers, name)
+    close(s.wake)
+    return s.n
+}
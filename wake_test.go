@@ -0,0 +1,30 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+func TestWake(t *testing.T) {
+    jh := New(context.Background())
+    if n := jh.Wake("poll"); n != 0 {
+        t.Fatal("woke jobs that are not sleeping", n)
+    }
+    woken := make(chan bool)
+    go func () {
+        woken <- jh.TrySleepNamed("poll", time.Hour)
+    }()
+    for jh.Wake("poll") == 0 {
+        time.Sleep(time.Millisecond)
+    }
+    if !<-woken {
+        t.Fatal("woken sleep reported stop")
+    }
+    go func () {
+        woken <- jh.TrySleepNamed("poll", time.Hour)
+    }()
+    jh.Stop()
+    if <-woken {
+        t.Fatal("sleep was not cancelled by stop")
+    }
+}
@@ -0,0 +1,64 @@
+package jobhandler
+
+import(
+    "log/slog"
+    "time"
+)
+
+// WithSlowJobThreshold makes the jobhandler watch the jobs taken with
+// TryJob and TryNamed while they run, and call fn once for each job that
+// has been running longer than d, e.g. to find the jobs holding up a
+// drain that never completes. The jobs are checked every d/2 until the
+// jobhandler is drained. If fn is nil, a warning is logged to the logger
+// set by WithLogger instead.
+// Unlike WithSlowJobLog, it reports jobs that never finish.
+func WithSlowJobThreshold(d time.Duration, fn func(JobInfo)) Option {
+    return func(jh *JobHandler) {
+        jh.stuckAfter = d
+        jh.onStuck = fn
+    }
+}
+
+// startWatchdog checks for stuck jobs until the jobhandler is drained.
+func (jh *JobHandler) startWatchdog() {
+    if jh.stuckAfter <= 0 {
+        return
+    }
+    drainedChan := jh.drainedChan
+    go func() {
+        for {
+            t := jh.clock().NewTimer(max(jh.stuckAfter / 2, time.Millisecond))
+            select {
+            case <-t.C():
+                jh.checkStuck()
+            case <-drainedChan:
+                t.Stop()
+                return
+            }
+        }
+    }()
+}
+
+// checkStuck reports the jobs that have been running longer than the
+// slow job threshold and were not reported before.
+func (jh *JobHandler) checkStuck() {
+    now := jh.clock().Now()
+    var stuck []*Job
+    jh.mu.Lock()
+    for j := range jh.jobs {
+        if !j.stuck && now.Sub(j.start) > jh.stuckAfter {
+            j.stuck = true
+            stuck = append(stuck, j)
+        }
+    }
+    jh.mu.Unlock()
+    for _, j := range stuck {
+        info := JobInfo{Name: j.name, Start: j.start, Elapsed: now.Sub(j.start)}
+        if jh.onStuck != nil {
+            jh.onStuck(info)
+        } else {
+            jh.log(slog.LevelWarn, "jobhandler: stuck job", "job", info.Name,
+                "start", info.Start, "elapsed", info.Elapsed)
+        }
+    }
+}
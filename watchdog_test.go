@@ -0,0 +1,30 @@
+package jobhandler
+import(
+    "context"
+    "testing"
+    "time"
+)
+
+func TestWithSlowJobThreshold(t *testing.T) {
+    stuck := make(chan JobInfo, 2)
+    jh := New(context.Background(), WithSlowJobThreshold(time.Millisecond, func(info JobInfo) {
+        stuck <- info
+    }))
+    job, ok := jh.TryNamed("reindex")
+    if !ok {
+        t.Fatal("failed to take job")
+    }
+    info := <-stuck
+    if info.Name != "reindex" || info.Elapsed <= time.Millisecond {
+        t.Fatal("unexpected stuck job", info)
+    }
+    time.Sleep(5 * time.Millisecond)
+    select {
+    case info := <-stuck:
+        t.Fatal("stuck job reported twice", info)
+    default:
+    }
+    jh.Stop()
+    job.Done()
+    jh.WaitAll()
+}